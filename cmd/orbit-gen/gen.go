@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// goType 生成的具名类型：Fields 非空时为 struct，否则为 type Name Underlying
+type goType struct {
+	Name       string
+	Doc        string
+	Fields     []goField
+	Underlying string
+}
+
+type goField struct {
+	Name string
+	Type string
+	Tag  string
+	Doc  string
+}
+
+type goArg struct {
+	Name string
+	Type string
+}
+
+type goParam struct {
+	Field string
+	Name  string // query / header 名
+}
+
+type goErr struct {
+	Var      string
+	Status   int // 0 表示 default
+	Code     int
+	Message  string
+	BodyType string // 错误响应体类型，空表示不解析
+}
+
+type goOp struct {
+	Name      string // Go 方法名
+	ID        string // 逻辑名，日志 path / span 名
+	Method    string
+	Path      string
+	Summary   string
+	PathFmt   string
+	PathArgs  []goArg
+	Params    string // query / header 参数 struct 名
+	Query     []goParam
+	Header    []goParam
+	BodyType  string
+	OutType   string
+	OutStruct bool // 返回 *OutType
+}
+
+type generator struct {
+	spec  *spec
+	pkg   string
+	src   string
+	types map[string]*goType
+	ops   []*goOp
+	errs  map[string][]goErr // op 名 → 错误映射
+}
+
+// generate 根据 spec 生成 package 源码（已 gofmt）
+func generate(s *spec, pkg, src string) ([]byte, error) {
+	g := &generator{spec: s, pkg: pkg, src: src, types: make(map[string]*goType), errs: make(map[string][]goErr)}
+
+	names := make([]string, 0, len(s.Components.Schemas))
+	for name := range s.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.define(schemaName(name), s.Components.Schemas[name])
+	}
+
+	paths := make([]string, 0, len(s.Paths))
+	for p := range s.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		item := s.Paths[p]
+		for _, m := range []struct {
+			method string
+			op     *operation
+		}{
+			{http.MethodGet, item.Get},
+			{http.MethodPut, item.Put},
+			{http.MethodPost, item.Post},
+			{http.MethodDelete, item.Delete},
+			{http.MethodPatch, item.Patch},
+			{http.MethodHead, item.Head},
+			{http.MethodOptions, item.Options},
+		} {
+			if m.op == nil {
+				continue
+			}
+			if err := g.operation(p, m.method, item, m.op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", m.method, p, err)
+			}
+		}
+	}
+
+	if err := g.checkNames(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := fileTmpl.Execute(&buf, g); err != nil {
+		return nil, err
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w\n%s", err, buf.Bytes())
+	}
+	return out, nil
+}
+
+func (g *generator) operation(path, method string, item *pathItem, op *operation) error {
+	id := op.OperationID
+	if id == "" {
+		id = strings.ToLower(method) + " " + path
+	}
+	o := &goOp{
+		Name:    goName(id),
+		ID:      g.pkg + "." + id,
+		Method:  method,
+		Path:    path,
+		Summary: op.Summary,
+	}
+
+	// path 级参数在前，operation 级同名参数覆盖
+	var params []*parameter
+	for _, raw := range append(slices.Clone(item.Parameters), op.Parameters...) {
+		p, err := g.spec.param(raw)
+		if err != nil {
+			return err
+		}
+		params = slices.DeleteFunc(params, func(q *parameter) bool { return q.Name == p.Name && q.In == p.In })
+		params = append(params, p)
+	}
+
+	var fields []goField
+	pathFmt := path
+	// 生成的方法体里用到的局部变量、receiver 和包级函数，path 参数不能与之同名
+	used := map[string]bool{
+		"ctx": true, "body": true, "params": true, "opts": true,
+		"req": true, "out": true, "err": true, "c": true, "addParam": true,
+	}
+	for _, p := range params {
+		typ := g.typeOf(p.Schema, o.Name+goName(p.Name), true)
+		switch p.In {
+		case "path":
+			arg := lowerFirst(goName(p.Name))
+			if used[arg] || isKeyword(arg) {
+				arg += "Param"
+			}
+			used[arg] = true
+			o.PathArgs = append(o.PathArgs, goArg{Name: arg, Type: typ})
+			pathFmt = strings.ReplaceAll(pathFmt, "{"+p.Name+"}", "%s")
+		case "query", "header":
+			f := goField{Name: goName(p.Name), Type: typ, Doc: p.Description}
+			f.Tag = fmt.Sprintf("`%s:%q`", p.In, p.Name)
+			fields = append(fields, f)
+			gp := goParam{Field: f.Name, Name: p.Name}
+			if p.In == "query" {
+				o.Query = append(o.Query, gp)
+			} else {
+				o.Header = append(o.Header, gp)
+			}
+		}
+	}
+	o.PathFmt = pathFmt
+	if len(fields) > 0 {
+		o.Params = o.Name + "Params"
+		g.types[o.Params] = &goType{Name: o.Params, Doc: id + " 的 query / header 参数，零值不发送", Fields: fields}
+	}
+
+	if op.RequestBody != nil {
+		if sc := jsonSchema(op.RequestBody.Content); sc != nil {
+			o.BodyType = g.typeOf(sc, o.Name+"Request", true)
+		}
+	}
+
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		r, err := g.spec.response(op.Responses[code])
+		if err != nil {
+			return err
+		}
+		sc := jsonSchema(r.Content)
+		status, _ := strconv.Atoi(code)
+		if status >= 200 && status < 300 {
+			if o.OutType == "" && sc != nil {
+				o.OutType = g.typeOf(sc, o.Name+"Response", true)
+				o.OutStruct = g.isStruct(o.OutType)
+			}
+			continue
+		}
+		if status == 0 && code != "default" {
+			// 2XX / 4XX 这类范围写法不生成映射
+			continue
+		}
+		// default 没有 x-error-code 时 Code 为 0，运行时取实际的 HTTP 状态码
+		e := goErr{Status: status, Code: status, Message: r.Description}
+		if r.ErrorCode != 0 {
+			e.Code = r.ErrorCode
+		}
+		suffix := "Default"
+		if status != 0 {
+			suffix = goName(http.StatusText(status))
+			if suffix == "" {
+				suffix = code
+			}
+			if e.Message == "" {
+				e.Message = http.StatusText(status)
+			}
+		}
+		e.Var = "Err" + o.Name + suffix
+		if sc != nil {
+			e.BodyType = g.typeOf(sc, o.Name+suffix+"Error", true)
+		}
+		g.errs[o.Name] = append(g.errs[o.Name], e)
+	}
+
+	g.ops = append(g.ops, o)
+	return nil
+}
+
+// define 为 components / 内联 object 生成具名类型
+func (g *generator) define(name string, sc *schema) {
+	if _, ok := g.types[name]; ok {
+		return
+	}
+	t := &goType{Name: name, Doc: sc.Description}
+	g.types[name] = t
+	if !isObject(sc) || len(sc.Properties) == 0 {
+		t.Underlying = g.typeOf(sc, name+"Item", false)
+		return
+	}
+	props := make([]string, 0, len(sc.Properties))
+	for p := range sc.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+	for _, p := range props {
+		ps := sc.Properties[p]
+		required := slices.Contains(sc.Required, p)
+		typ := g.typeOf(ps, name+goName(p), true)
+		tag := p
+		if !required {
+			tag += ",omitempty"
+			if g.isStruct(typ) {
+				typ = "*" + typ
+			}
+		}
+		t.Fields = append(t.Fields, goField{
+			Name: goName(p),
+			Type: typ,
+			Tag:  fmt.Sprintf("`json:%q`", tag),
+			Doc:  ps.Description,
+		})
+	}
+}
+
+// typeOf schema 对应的 Go 类型；nameHint 用于内联 object 生成的类型名，named 为 false 时内联 object 用 map
+func (g *generator) typeOf(sc *schema, nameHint string, named bool) string {
+	if sc == nil {
+		return "any"
+	}
+	if sc.Ref != "" {
+		return schemaName(refName(sc.Ref))
+	}
+	switch sc.Type {
+	case "string":
+		return "string"
+	case "integer":
+		if sc.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if sc.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.typeOf(sc.Items, nameHint+"Item", true)
+	}
+	if isObject(sc) {
+		if len(sc.Properties) > 0 && named {
+			g.define(nameHint, sc)
+			return nameHint
+		}
+		if sc.AdditionalProperties != nil && sc.AdditionalProperties.schema != nil {
+			return "map[string]" + g.typeOf(sc.AdditionalProperties.schema, nameHint+"Value", true)
+		}
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// reservedNames 模板自身生成的包级标识符，类型、错误码变量不能与之同名
+var reservedNames = map[string]string{
+	"Client": "the generated client type",
+	"New":    "the generated constructor",
+}
+
+// schemaName components 里的 schema 对应的类型名，与 reservedNames 同名时加 Model 后缀
+func schemaName(name string) string {
+	n := goName(name)
+	if _, ok := reservedNames[n]; ok {
+		n += "Model"
+	}
+	return n
+}
+
+// checkNames 生成的类型、错误码变量与模板里的标识符不能重名，否则生成的代码无法编译
+func (g *generator) checkNames() error {
+	owners := make(map[string]string, len(reservedNames)+len(g.types))
+	for name, owner := range reservedNames {
+		owners[name] = owner
+	}
+	claim := func(name, owner string) error {
+		if prev, ok := owners[name]; ok {
+			return fmt.Errorf("generated name %s is used by both %s and %s, rename one of them in the spec", name, prev, owner)
+		}
+		owners[name] = owner
+		return nil
+	}
+	for _, t := range g.Types() {
+		if err := claim(t.Name, "type "+t.Name); err != nil {
+			return err
+		}
+	}
+	for _, e := range g.AllErrors() {
+		if err := claim(e.Var, "the error code of "+strings.TrimPrefix(e.Var, "Err")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) isStruct(typ string) bool {
+	t, ok := g.types[typ]
+	return ok && len(t.Fields) > 0
+}
+
+func isObject(sc *schema) bool {
+	return sc.Type == "object" || (sc.Type == "" && sc.Ref == "" && (len(sc.Properties) > 0 || sc.AdditionalProperties != nil))
+}
+
+// -------------------- 模板数据 --------------------
+
+// MethodConst "GET" → "http.MethodGet"
+func (o *goOp) MethodConst() string {
+	return "http.Method" + o.Method[:1] + strings.ToLower(o.Method[1:])
+}
+
+func (g *generator) Pkg() string    { return g.pkg }
+func (g *generator) Source() string { return g.src }
+func (g *generator) Title() string  { return g.spec.Info.Title }
+func (g *generator) Ops() []*goOp   { return g.ops }
+
+func (g *generator) Types() []*goType {
+	out := make([]*goType, 0, len(g.types))
+	for _, t := range g.types {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (g *generator) Errors(op string) []goErr { return g.errs[op] }
+
+func (g *generator) AllErrors() []goErr {
+	var out []goErr
+	for _, o := range g.ops {
+		out = append(out, g.errs[o.Name]...)
+	}
+	return out
+}
+
+func (g *generator) HasParams() bool {
+	for _, o := range g.ops {
+		if o.Params != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// -------------------- 命名 --------------------
+
+var initialisms = map[string]string{"id": "ID", "url": "URL", "uri": "URI", "http": "HTTP", "api": "API", "uuid": "UUID", "json": "JSON"}
+
+// goName "get_pet-by id" / "getPetById" → "GetPetByID"
+func goName(s string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		for _, word := range splitCamel(part) {
+			if up, ok := initialisms[strings.ToLower(word)]; ok {
+				b.WriteString(up)
+				continue
+			}
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "X" + name
+	}
+	return name
+}
+
+// splitCamel "getPetById" → ["get", "Pet", "By", "Id"]
+func splitCamel(s string) []string {
+	var words []string
+	start := 0
+	for i := 1; i < len(s); i++ {
+		if unicode.IsUpper(rune(s[i])) && !unicode.IsUpper(rune(s[i-1])) {
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	return append(words, s[start:])
+}
+
+func lowerFirst(s string) string {
+	for i, r := range s {
+		if !unicode.IsUpper(r) {
+			if i > 1 {
+				// "ID" → "id"，"URLPath" → "urlPath"
+				i--
+			}
+			return strings.ToLower(s[:max(i, 1)]) + s[max(i, 1):]
+		}
+	}
+	return strings.ToLower(s)
+}
+
+func isKeyword(s string) bool {
+	switch s {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func",
+		"go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct",
+		"switch", "type", "var", "url", "http", "fmt", "json", "errorx", "httpclient", "context", "reflect":
+		return true
+	}
+	return false
+}
+
+var fileTmpl = template.Must(template.New("file").Funcs(template.FuncMap{
+	"lower": lowerFirst,
+}).Parse(`// Code generated by orbit-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Pkg}}
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/httpclient"
+)
+
+// Client {{.Title}} 的类型化 client，传输、重试、日志由 httpclient.Client 负责
+type Client struct {
+	hc *httpclient.Client
+}
+
+func New(hc *httpclient.Client) *Client {
+	return &Client{hc: hc}
+}
+
+// -------------------- 类型 --------------------
+{{range .Types}}
+{{- if .Doc}}
+// {{.Name}} {{.Doc}}
+{{- end}}
+{{- if .Fields}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} {{.Tag}}{{if .Doc}} // {{.Doc}}{{end}}
+{{- end}}
+}
+{{else}}
+type {{.Name}} {{.Underlying}}
+{{end}}
+{{- end}}
+
+// -------------------- 错误码 --------------------
+
+var (
+{{- range .AllErrors}}
+	{{.Var}} = errorx.CodeEntry{Code: {{.Code}}, Message: {{printf "%q" .Message}}}
+{{- end}}
+)
+
+{{range $op := .Ops}}
+// {{.Name}} {{.Method}} {{.Path}}{{if .Summary}}
+// {{.Summary}}{{end}}
+func (c *Client) {{.Name}}(ctx context.Context
+	{{- range .PathArgs}}, {{.Name}} {{.Type}}{{end}}
+	{{- if .Params}}, params *{{.Params}}{{end}}
+	{{- if .BodyType}}, body {{.BodyType}}{{end}}, opts ...httpclient.RequestOption) (
+	{{- if .OutType}}{{if .OutStruct}}*{{end}}{{.OutType}}, {{end}}error) {
+	req := &httpclient.Request{Method: {{.MethodConst}}, Operation: {{printf "%q" .ID}}
+	{{- if .BodyType}}, Body: body{{end}}}
+	httpclient.WithPathTemplate({{printf "%q" .PathFmt}}{{range .PathArgs}}, url.PathEscape(fmt.Sprint({{.Name}})){{end}})(req)
+	{{- if .Params}}
+	if params != nil {
+		{{- if .Query}}
+		req.Query = url.Values{}
+		{{- range .Query}}
+		addParam(req.Query.Add, {{printf "%q" .Name}}, params.{{.Field}})
+		{{- end}}
+		{{- end}}
+		{{- if .Header}}
+		req.Headers = http.Header{}
+		{{- range .Header}}
+		addParam(req.Headers.Add, {{printf "%q" .Name}}, params.{{.Field}})
+		{{- end}}
+		{{- end}}
+	}
+	{{- end}}
+	{{- if .OutType}}
+	var out {{.OutType}}
+	if err := c.do(ctx, req, &out, {{lower .Name}}Errors, opts); err != nil {
+		return {{if .OutStruct}}nil{{else}}out{{end}}, err
+	}
+	return {{if .OutStruct}}&{{end}}out, nil
+	{{- else}}
+	return c.do(ctx, req, nil, {{lower .Name}}Errors, opts)
+	{{- end}}
+}
+
+var {{lower .Name}}Errors = map[int]apiError{
+{{- range $.Errors .Name}}
+	{{.Status}}: {code: {{.Var}}{{if .BodyType}}, body: func() any { return new({{.BodyType}}) }{{end}}},
+{{- end}}
+}
+{{end}}
+
+// -------------------- 公共 --------------------
+
+// apiError 某个状态码对应的错误码和错误响应体类型，key 0 表示 default（错误码为 0 时使用 HTTP 状态码）
+type apiError struct {
+	code errorx.CodeEntry
+	body func() any
+}
+
+// do 发请求：2xx 时把响应体解码到 out；非 2xx 时按 errs 映射为 errorx 错误，
+// 能解析的错误响应体放在 Fields["body"]
+func (c *Client) do(ctx context.Context, req *httpclient.Request, out any, errs map[int]apiError, opts []httpclient.RequestOption) error {
+	for _, opt := range opts {
+		opt(req)
+	}
+	var raw []byte
+	resp, err := c.hc.Do(ctx, req, &raw)
+	if err != nil {
+		if resp == nil || (resp.StatusCode >= 200 && resp.StatusCode < 300) {
+			return err
+		}
+		return mapError(req.Operation, resp.StatusCode, raw, err, errs)
+	}
+	if out == nil || len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("%s: decode response: %w", req.Operation, err)
+	}
+	return nil
+}
+
+func mapError(op string, status int, raw []byte, err error, errs map[int]apiError) error {
+	e, ok := errs[status]
+	if !ok {
+		if e, ok = errs[0]; !ok {
+			return err
+		}
+	}
+	code := e.code
+	if code.Code == 0 {
+		code.Code = status
+	}
+	opts := []errorx.Option{
+		errorx.WithCause(err),
+		errorx.WithService(errorx.ServiceOf(err)),
+		errorx.WithField("operation", op),
+		errorx.WithField("status", status),
+	}
+	if status < 500 {
+		// 文档里声明的 4xx 视为业务错误
+		opts = append(opts, errorx.WithType(errorx.ErrTypeBiz))
+	}
+	if e.body != nil {
+		if body := e.body(); json.Unmarshal(raw, body) == nil {
+			opts = append(opts, errorx.WithField("body", body))
+		}
+	}
+	return errorx.New(code, opts...)
+}
+
+// addParam 零值不发送，slice 展开为多个值
+func addParam(add func(k, v string), k string, v any) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.IsZero() {
+		return
+	}
+	if rv.Kind() == reflect.Slice {
+		for i := 0; i < rv.Len(); i++ {
+			add(k, fmt.Sprint(rv.Index(i).Interface()))
+		}
+		return
+	}
+	add(k, fmt.Sprint(v))
+}
+`))
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeneratePetstore(t *testing.T) {
+	s, err := loadSpec("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(s, "petstore", "petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "client_gen.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("petstore", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("generated code does not type-check: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		`func (c *Client) GetPetByID(ctx context.Context, petID int64, opts ...httpclient.RequestOption) (*Pet, error)`,
+		`func (c *Client) ListPets(ctx context.Context, params *ListPetsParams, opts ...httpclient.RequestOption) ([]Pet, error)`,
+		`Operation: "petstore.getPetById"`,
+		`ErrCreatePetConflict  = errorx.CodeEntry{Code: 20001, Message: "pet already exists"}`,
+		`Owner *PetOwner`,
+		// 与生成代码里的 url 包、局部变量同名的 path 参数要改名
+		`func (c *Client) GetPetLink(ctx context.Context, petID int64, urlParam string, reqParam string, errParam string, params *GetPetLinkParams, opts ...httpclient.RequestOption) (*Pet, error)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"getPetById":   "GetPetByID",
+		"X-Request-Id": "XRequestID",
+		"pet_id":       "PetID",
+		"2fa":          "X2fa",
+	} {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// loadInline 把 YAML 写到临时文件再加载
+func loadInline(t *testing.T, doc string) *spec {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// 与生成的 Client / New 同名的 schema 改名；其他无法改名的重名直接报错
+func TestGenerateReservedNames(t *testing.T) {
+	s := loadInline(t, `
+openapi: 3.0.3
+info: {title: Reserved, version: 1.0.0}
+paths:
+  /clients/{id}:
+    get:
+      operationId: getClient
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: string}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Client"}
+        "404":
+          description: not found
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/New"}
+components:
+  schemas:
+    Client:
+      type: object
+      properties:
+        name: {type: string}
+    New:
+      type: object
+      properties:
+        reason: {type: string}
+`)
+	src, err := generate(s, "reserved", "spec.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "client_gen.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("reserved", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("generated code does not type-check: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		`type ClientModel struct`,
+		`type NewModel struct`,
+		`func (c *Client) GetClient(ctx context.Context, id string, opts ...httpclient.RequestOption) (*ClientModel, error)`,
+		`return new(NewModel)`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	s = loadInline(t, `
+openapi: 3.0.3
+info: {title: Clash, version: 1.0.0}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "404": {description: not found}
+components:
+  schemas:
+    ErrListPetsNotFound: {type: string}
+`)
+	if _, err := generate(s, "clash", "spec.yaml"); err == nil || !strings.Contains(err.Error(), "ErrListPetsNotFound") {
+		t.Fatalf("want name clash error, got %v", err)
+	}
+}
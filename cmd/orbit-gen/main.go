@@ -0,0 +1,53 @@
+// orbit-gen 根据 OpenAPI 3 描述生成基于 httpclient.Client 的类型化 client：
+//
+//	orbit-gen -spec api/user.yaml -pkg userapi -out internal/userapi
+//
+// 也可以写在 //go:generate 里。每个 operation 生成一个方法，
+// 日志 path / span 名为 "<pkg>.<operationId>"，文档里声明的错误响应映射为 errorx 错误码
+// （响应上的 x-error-code 可覆盖默认的 HTTP 状态码）
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	specPath := flag.String("spec", "", "OpenAPI 3 文件（YAML / JSON）")
+	pkg := flag.String("pkg", "", "生成的 package 名，默认取 -out 目录名")
+	out := flag.String("out", ".", "输出目录")
+	file := flag.String("file", "client_gen.go", "输出文件名")
+	flag.Parse()
+
+	if err := run(*specPath, *pkg, *out, *file); err != nil {
+		fmt.Fprintln(os.Stderr, "orbit-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, pkg, out, file string) error {
+	if specPath == "" {
+		return fmt.Errorf("-spec is required")
+	}
+	if pkg == "" {
+		abs, err := filepath.Abs(out)
+		if err != nil {
+			return err
+		}
+		pkg = filepath.Base(abs)
+	}
+	s, err := loadSpec(specPath)
+	if err != nil {
+		return err
+	}
+	src, err := generate(s, pkg, filepath.Base(specPath))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(out, file), src, 0o644)
+}
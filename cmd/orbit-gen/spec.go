@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// 只解析生成代码需要的 OpenAPI 3 子集，YAML / JSON 均可
+
+type spec struct {
+	Info struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Paths      map[string]*pathItem `yaml:"paths"`
+	Components struct {
+		Schemas    map[string]*schema    `yaml:"schemas"`
+		Parameters map[string]*parameter `yaml:"parameters"`
+		Responses  map[string]*response  `yaml:"responses"`
+	} `yaml:"components"`
+}
+
+type pathItem struct {
+	Parameters []*parameter `yaml:"parameters"`
+	Get        *operation   `yaml:"get"`
+	Put        *operation   `yaml:"put"`
+	Post       *operation   `yaml:"post"`
+	Delete     *operation   `yaml:"delete"`
+	Patch      *operation   `yaml:"patch"`
+	Head       *operation   `yaml:"head"`
+	Options    *operation   `yaml:"options"`
+}
+
+type operation struct {
+	OperationID string               `yaml:"operationId"`
+	Summary     string               `yaml:"summary"`
+	Parameters  []*parameter         `yaml:"parameters"`
+	RequestBody *requestBody         `yaml:"requestBody"`
+	Responses   map[string]*response `yaml:"responses"`
+}
+
+type parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"` // path / query / header
+	Required    bool    `yaml:"required"`
+	Description string  `yaml:"description"`
+	Schema      *schema `yaml:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `yaml:"required"`
+	Content  map[string]*mediaType `yaml:"content"`
+}
+
+type response struct {
+	Ref         string                `yaml:"$ref"`
+	Description string                `yaml:"description"`
+	Content     map[string]*mediaType `yaml:"content"`
+	ErrorCode   int                   `yaml:"x-error-code"` // 映射到的 errorx code，默认用 HTTP 状态码
+}
+
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 string             `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Description          string             `yaml:"description"`
+	Properties           map[string]*schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	Items                *schema            `yaml:"items"`
+	AdditionalProperties *additionalProps   `yaml:"additionalProperties"`
+	Nullable             bool               `yaml:"nullable"`
+}
+
+// additionalProps 可能是 bool，也可能是 schema
+type additionalProps struct {
+	schema *schema
+}
+
+func (a *additionalProps) UnmarshalYAML(b []byte) error {
+	switch strings.TrimSpace(string(b)) {
+	case "true", "false":
+		return nil
+	}
+	a.schema = new(schema)
+	return yaml.Unmarshal(b, a.schema)
+}
+
+func loadSpec(path string) (*spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s spec
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(s.Paths) == 0 {
+		return nil, fmt.Errorf("%s: no paths", path)
+	}
+	return &s, nil
+}
+
+// refName "#/components/schemas/Pet" → "Pet"
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func (s *spec) param(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	if rp, ok := s.Components.Parameters[refName(p.Ref)]; ok {
+		return rp, nil
+	}
+	return nil, fmt.Errorf("unresolved parameter %s", p.Ref)
+}
+
+func (s *spec) response(r *response) (*response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	if rr, ok := s.Components.Responses[refName(r.Ref)]; ok {
+		return rr, nil
+	}
+	return nil, fmt.Errorf("unresolved response %s", r.Ref)
+}
+
+// jsonSchema 取 application/json（或 +json）的 schema
+func jsonSchema(content map[string]*mediaType) *schema {
+	for ct, mt := range content {
+		base, _, _ := strings.Cut(ct, ";")
+		if base == "application/json" || strings.HasSuffix(base, "+json") {
+			return mt.Schema
+		}
+	}
+	return nil
+}
@@ -54,3 +54,24 @@ var (
 	ErrDefault  = CodeEntry{Code: 1000, Message: "未知错误"}
 	ErrNotFound = CodeEntry{Code: 404, Message: "not found"}
 )
+
+// -------------------- httpclient 错误 --------------------
+
+var (
+	ErrChecksumMismatch   = CodeEntry{Code: 1101, Message: "checksum mismatch"}
+	ErrDownloadIncomplete = CodeEntry{Code: 1102, Message: "download incomplete"}
+	ErrPageLimitExceeded  = CodeEntry{Code: 1103, Message: "page limit exceeded"}
+	ErrFaultInjected      = CodeEntry{Code: 1104, Message: "fault injected"}
+	ErrBatchFailed        = CodeEntry{Code: 1105, Message: "batch partially failed"}
+	ErrConcurrencyLimited = CodeEntry{Code: 1106, Message: "concurrency limit exceeded"}
+	ErrHttpCall           = CodeEntry{Code: 1107, Message: "http call failed"}
+	ErrClientClosed       = CodeEntry{Code: 1108, Message: "client closed"}
+	ErrResponseTooLarge   = CodeEntry{Code: 1109, Message: "response too large"}
+	ErrResponseDecode     = CodeEntry{Code: 1110, Message: "response decode failed"}
+	ErrGraphQL            = CodeEntry{Code: 1111, Message: "graphql error"}
+	ErrJSONRPC            = CodeEntry{Code: 1112, Message: "jsonrpc error"}
+	ErrWebsocketHandshake = CodeEntry{Code: 1113, Message: "websocket handshake failed"}
+	ErrWebsocketClosed    = CodeEntry{Code: 1114, Message: "websocket closed"}
+	ErrNilRequest         = CodeEntry{Code: 1115, Message: "nil request"}
+	ErrDownloadWrite      = CodeEntry{Code: 1116, Message: "download write failed"}
+)
@@ -0,0 +1,157 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/tracex"
+)
+
+const defaultBatchConcurrency = 8
+
+// BatchCall 批量调用中的一项，Out 与 Do 的 respBody 语义相同
+type BatchCall struct {
+	Request *Request
+	Out     any
+}
+
+// BatchResult 与 BatchCall 一一对应
+type BatchResult struct {
+	Resp *http.Response
+	Err  error
+	Cost time.Duration
+}
+
+type batchConfig struct {
+	concurrency int
+	timeout     time.Duration
+	failFast    bool
+}
+
+type BatchOption func(*batchConfig)
+
+// WithConcurrency 最大并发数，默认 8
+func WithConcurrency(n int) BatchOption {
+	return func(c *batchConfig) { c.concurrency = n }
+}
+
+// WithBatchTimeout 整批共享的 deadline，<=0 时只受 ctx 控制
+func WithBatchTimeout(d time.Duration) BatchOption {
+	return func(c *batchConfig) { c.timeout = d }
+}
+
+// WithFailFast 任意一项失败后取消其余请求；默认 collect-all，全部跑完再汇总
+func WithFailFast() BatchOption {
+	return func(c *batchConfig) { c.failFast = true }
+}
+
+// BatchFailure 聚合错误里的单项失败信息（errorx.Error.Fields["failed"]）
+type BatchFailure struct {
+	Index  int    `json:"index"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Err    string `json:"err"`
+}
+
+// DoBatch 并发执行一批请求，结果与 calls 顺序一致；
+// 有失败项时返回 errorx.ErrBatchFailed，Fields["failed"] 列出失败项，Cause 为 errors.Join 后的各项错误。
+// Request 为 nil 的项直接记为 errorx.ErrNilRequest。所有请求挂在同一个 "http_batch" span 下
+func (c *Client) DoBatch(ctx context.Context, calls []BatchCall, opts ...BatchOption) ([]BatchResult, error) {
+	cfg := batchConfig{concurrency: defaultBatchConcurrency}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.concurrency <= 0 {
+		cfg.concurrency = defaultBatchConcurrency
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, _ = tracex.StartSpan(ctx, "http_batch")
+	var batchErr error
+	defer func() {
+		tracex.EndSpan(ctx, batchErr)
+	}()
+
+	var cancel context.CancelFunc
+	if cfg.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	results := make([]BatchResult, len(calls))
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		if call.Request == nil {
+			results[i].Err = errorx.New(errorx.ErrNilRequest, append([]errorx.Option{
+				errorx.WithMessage(fmt.Sprintf("batch call #%d has nil Request", i)),
+			}, c.serviceOpts()...)...)
+			if cfg.failFast {
+				cancel()
+			}
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// 超时 / fail-fast 后未启动的项直接记为失败
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			start := time.Now()
+			resp, err := c.Do(ctx, call.Request, call.Out)
+			results[i] = BatchResult{Resp: resp, Err: err, Cost: time.Since(start)}
+			if err != nil && cfg.failFast {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	batchErr = c.batchError(calls, results)
+	return results, batchErr
+}
+
+// batchError 汇总失败项，全部成功时返回 nil
+func (c *Client) batchError(calls []BatchCall, results []BatchResult) error {
+	var (
+		failed []BatchFailure
+		errs   []error
+	)
+	for i, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		f := BatchFailure{Index: i, Err: r.Err.Error()}
+		if req := calls[i].Request; req != nil {
+			f.Method, f.Path = req.Method, req.Path
+		}
+		failed = append(failed, f)
+		errs = append(errs, fmt.Errorf("#%d: %w", i, r.Err))
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	opts := append([]errorx.Option{
+		errorx.WithMessage(fmt.Sprintf("%d of %d calls failed", len(failed), len(results))),
+		errorx.WithCause(errors.Join(errs...)),
+		errorx.WithField("failed", failed),
+	}, c.serviceOpts()...)
+	return errorx.New(errorx.ErrBatchFailed, opts...)
+}
@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+func TestDoBatchOrderAndConcurrency(t *testing.T) {
+	var inflight, peak atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/")))
+	}))
+	defer srv.Close()
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	calls := make([]BatchCall, 12)
+	outs := make([][]byte, len(calls))
+	for i := range calls {
+		calls[i] = BatchCall{Request: &Request{Method: http.MethodGet, Path: fmt.Sprintf("/%d", i)}, Out: &outs[i]}
+	}
+	results, err := c.DoBatch(context.Background(), calls, WithConcurrency(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p > 3 || p < 2 {
+		t.Errorf("peak concurrency = %d, want <= 3", p)
+	}
+	for i, r := range results {
+		if r.Err != nil || string(outs[i]) != fmt.Sprint(i) {
+			t.Errorf("#%d: out=%q err=%v", i, outs[i], r.Err)
+		}
+	}
+}
+
+func TestDoBatchAggregateError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	var out map[string]any
+	calls := []BatchCall{
+		{Request: &Request{Method: http.MethodGet, Path: "/ok"}, Out: &out},
+		{Request: &Request{Method: http.MethodGet, Path: "/bad"}},
+		{Request: nil}, // 不能让 worker panic
+		{Request: &Request{Method: http.MethodGet, Path: "/ok"}},
+	}
+	results, err := c.DoBatch(context.Background(), calls)
+	if !hasCode(err, errorx.ErrBatchFailed) {
+		t.Fatalf("err = %v, want ErrBatchFailed", err)
+	}
+	if results[0].Err != nil || results[3].Err != nil {
+		t.Fatalf("successful calls failed: %v / %v", results[0].Err, results[3].Err)
+	}
+	if !hasCode(results[1].Err, errorx.CodeEntry{Code: http.StatusInternalServerError}) {
+		t.Errorf("#1 err = %v", results[1].Err)
+	}
+	if !hasCode(results[2].Err, errorx.ErrNilRequest) {
+		t.Errorf("#2 err = %v", results[2].Err)
+	}
+	for _, i := range []int{1, 2} {
+		if !errors.Is(err, results[i].Err) {
+			t.Errorf("aggregate does not wrap #%d", i)
+		}
+	}
+	e, _ := errorx.From(err)
+	failed, _ := e.Fields["failed"].([]BatchFailure)
+	if len(failed) != 2 || failed[0].Index != 1 || failed[0].Path != "/bad" || failed[1].Index != 2 {
+		t.Errorf("failed = %+v", failed)
+	}
+}
+
+func TestDoBatchCancel(t *testing.T) {
+	started := make(chan struct{}, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	calls := make([]BatchCall, 6)
+	for i := range calls {
+		calls[i] = BatchCall{Request: &Request{Method: http.MethodGet, Path: "/slow"}}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	done := make(chan struct{})
+	var results []BatchResult
+	go func() {
+		defer close(done)
+		results, err = c.DoBatch(ctx, calls, WithConcurrency(2))
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("DoBatch did not return after ctx was canceled")
+	}
+	if !hasCode(err, errorx.ErrBatchFailed) {
+		t.Fatalf("err = %v", err)
+	}
+	for i, r := range results {
+		if r.Err == nil {
+			t.Errorf("#%d succeeded after cancel", i)
+		}
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("aggregate does not wrap context.Canceled: %v", err)
+	}
+}
@@ -2,11 +2,16 @@ package httpclient
 
 import (
 	"context"
-	"github.com/imattdu/orbit/logx"
 	"log/slog"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/logx"
 )
 
 // Hook 在请求前后执行
@@ -16,8 +21,14 @@ type AfterFunc func(ctx context.Context, req *http.Request, resp *http.Response,
 
 // Config 是 Client 的初始化配置
 type Config struct {
-	logger  logx.Logger
-	BaseURL string
+	logger    logx.Logger
+	ownLogger bool // logger 由 client 负责关闭
+	BaseURL   string
+	// 多个 BaseURL 时按调用轮询（与 BaseURL 合并）
+	BaseURLs []string
+
+	// 下游服务标识，写入 client 产生的 errorx.Error.Service
+	Service errorx.CodeEntry
 
 	// 请求级默认超时（per-request 没设 Timeout 时使用）
 	DefaultTimeout time.Duration
@@ -32,10 +43,24 @@ type Config struct {
 	IdleConnTimeout       time.Duration
 	ReadWriteTimeout      time.Duration // 每次 Read/Write 的 deadline
 
+	// 拨号相关
+	DialContext   DialFunc        // 自定义拨号，nil 使用 net.Dialer
+	UnixSocket    string          // 所有连接走该 unix socket
+	H2C           bool            // 明文 HTTP/2 prior knowledge
+	DNSCache      *DNSCacheConfig // DNS 缓存，nil 表示不缓存
+	FallbackDelay time.Duration   // happy eyeballs 回退等待，0 为默认 300ms，<0 关闭
+
+	// 代理，nil 时读取环境变量（HTTP_PROXY / NO_PROXY）
+	Proxy *ProxyConfig
+
 	// 重试相关
 	RetryMaxAttempts int
 	RetryDecider     RetryDecider
 	RetryBackoff     BackoffFunc
+	RetryPolicy      RetryPolicy
+
+	// 幂等键 header，非空时为非幂等方法自动生成幂等键
+	IdempotencyHeader string
 
 	// 业务错误解析
 	BizErrDecoder BizErrorDecoder
@@ -46,6 +71,27 @@ type Config struct {
 
 	// 调用统计上报（例如打日志）
 	StatsHook StatsHook
+
+	// 故障注入（测试 / 演练用）
+	FaultInjector *FaultInjector
+
+	// 按下游 host 的自适应并发限制，nil 表示不限制
+	AdaptiveLimit func() *AdaptiveLimiter
+
+	// 失败时生成 curl 命令；RedactHeaders 追加到默认脱敏列表
+	CurlOnFailure bool
+	RedactHeaders []string
+
+	// 影子流量
+	Shadow *ShadowConfig
+
+	// 响应体读取 / 解码
+	MaxResponseBytes int64 // <0 表示不限制
+	Decode           DecodeOptions
+
+	// client 级 cookie jar（默认不开启）
+	EnableCookies bool
+	CookieJar     http.CookieJar
 }
 
 func defaultConfig() Config {
@@ -60,6 +106,7 @@ func defaultConfig() Config {
 		IdleConnTimeout:       90 * time.Second,
 		ReadWriteTimeout:      5 * time.Second,
 		RetryMaxAttempts:      1,
+		MaxResponseBytes:      defaultMaxResponseBytes,
 	}
 }
 
@@ -69,6 +116,20 @@ func WithBaseURL(s string) Option {
 	return func(c *Config) { c.BaseURL = s }
 }
 
+func WithBaseURLs(s ...string) Option {
+	return func(c *Config) { c.BaseURLs = append(c.BaseURLs, s...) }
+}
+
+// WithLogger 使用外部 logger，不设置时 New 会创建默认的 http logger
+func WithLogger(l logx.Logger) Option {
+	return func(c *Config) { c.logger = l }
+}
+
+// WithService 设置下游服务标识，client 返回的 errorx.Error 会带上它
+func WithService(s errorx.CodeEntry) Option {
+	return func(c *Config) { c.Service = s }
+}
+
 func WithDefaultTimeout(t time.Duration) Option {
 	return func(c *Config) { c.DefaultTimeout = t }
 }
@@ -93,6 +154,11 @@ func WithRetry(max int, decider RetryDecider, backoff BackoffFunc) Option {
 	}
 }
 
+// WithRetryPolicy 设置能感知请求的重试策略，例如 RetryIdempotent
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Config) { c.RetryPolicy = p }
+}
+
 func WithBizErrorDecoder(dec BizErrorDecoder) Option {
 	return func(c *Config) { c.BizErrDecoder = dec }
 }
@@ -103,28 +169,55 @@ func WithStatsHook(h StatsHook) Option {
 
 // Client 是并发安全的 HTTP 客户端
 type Client struct {
-	logger  logx.Logger
-	hc      *http.Client
-	baseURL *url.URL
+	logger   logx.Logger
+	hc       *http.Client
+	baseURLs []*url.URL
+	nextBase atomic.Uint64
+	service  errorx.CodeEntry
 
 	before []BeforeFunc
 	after  []AfterFunc
 
 	defaultTimeout   time.Duration
 	retryMaxAttempts int
-	retryDecider     RetryDecider
+	retryPolicy      RetryPolicy
 	backoff          BackoffFunc
 	bizErrDecoder    BizErrorDecoder
 	statsHook        StatsHook
+
+	idempotencyHeader string
+	faults            *FaultInjector
+	newLimiter        func() *AdaptiveLimiter
+	limiters          sync.Map // host -> *AdaptiveLimiter
+
+	curlOnFailure bool
+	redact        redactor
+	dialTimeout   time.Duration
+	proxy         proxyFunc
+	unixSocket    string            // Config.UnixSocket，curl 命令用
+	sockets       map[string]string // unix:// base URL 的占位 host → socket 路径，curl 命令用
+	h2c           bool
+	wsTransport   *http.Transport // WebSocket 握手用，升级后的长连接不设读写超时（由 ping 保活）
+	shadow        *shadower
+
+	maxResponseBytes int64
+	decode           DecodeOptions
+
+	// 优雅关闭
+	ownsLogger bool
+	closeMu    sync.RWMutex
+	closed     bool
+	inflight   sync.WaitGroup
 }
 
-// New 创建 Client，Config 初始化后不再修改 → 并发安全
-func New(opts ...Option) (*Client, error) {
+// New 创建 Client，Config 初始化后不再修改 → 并发安全；
+// 失败时关闭 client 自有的 logger
+func New(opts ...Option) (_ *Client, err error) {
 	cfg := defaultConfig()
 	for _, opt := range opts {
 		opt(&cfg)
 	}
-	logger := cfg.logger
+	logger, ownsLogger := cfg.logger, cfg.ownLogger
 	if logger == nil {
 		l, err := logx.New(logx.Config{
 			AppName:    "http",
@@ -136,19 +229,63 @@ func New(opts ...Option) (*Client, error) {
 		if err != nil {
 			return nil, err
 		}
-		logger = l
+		logger, ownsLogger = l, true
+	}
+	if ownsLogger {
+		defer func() {
+			if err != nil {
+				_ = logger.Close(context.Background())
+			}
+		}()
 	}
 
-	var base *url.URL
-	if cfg.BaseURL != "" {
-		u, err := url.Parse(cfg.BaseURL)
+	var bases []*url.URL
+	sockets := make(map[string]string) // unix:// base URL 的占位 host → socket 路径
+	for _, s := range append([]string{cfg.BaseURL}, cfg.BaseURLs...) {
+		if s == "" {
+			continue
+		}
+		u, err := url.Parse(s)
 		if err != nil {
 			return nil, err
 		}
-		base = u
+		if u.Scheme == "unix" {
+			host := unixHost(u.Path)
+			sockets[host] = u.Path
+			u = &url.URL{Scheme: "http", Host: host}
+		}
+		bases = append(bases, u)
 	}
 
-	tr := buildTransport(&cfg)
+	shadow, err := newShadower(cfg.Shadow)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := newProxyFunc(&cfg, sockets)
+	if err != nil {
+		return nil, err
+	}
+	tr := buildTransport(&cfg, sockets, proxy)
+	// WebSocket 握手必须是 HTTP/1.1 Upgrade：不继承 h2c，也不协商 HTTP/2
+	wsCfg := cfg
+	wsCfg.ReadWriteTimeout = 0
+	wsCfg.H2C = false
+	wsTransport := buildTransport(&wsCfg, sockets, proxy)
+	wsTransport.ForceAttemptHTTP2 = false
+	wsTransport.Protocols = new(http.Protocols)
+	wsTransport.Protocols.SetHTTP1(true)
+
+	if shadow != nil {
+		shadow.hc = &http.Client{Transport: tr}
+	}
+
+	jar := cfg.CookieJar
+	if cfg.EnableCookies && jar == nil {
+		if jar, err = cookiejar.New(nil); err != nil {
+			return nil, err
+		}
+	}
 
 	maxAttempts := cfg.RetryMaxAttempts
 	if maxAttempts <= 0 {
@@ -158,24 +295,50 @@ func New(opts ...Option) (*Client, error) {
 	if dec == nil {
 		dec = defaultRetryDecider
 	}
+	policy := cfg.RetryPolicy
+	if policy == nil {
+		policy = func(_ *http.Request, resp *http.Response, err error) bool {
+			return dec(resp, err)
+		}
+	}
 	bf := cfg.RetryBackoff
 	if bf == nil {
 		bf = defaultBackoff
 	}
 
 	return &Client{
-		logger:  logger,
-		hc:      &http.Client{Transport: tr},
-		baseURL: base,
+		logger:   logger,
+		hc:       &http.Client{Transport: tr, Jar: jar},
+		baseURLs: bases,
+		service:  cfg.Service,
 
 		before: append([]BeforeFunc(nil), cfg.Before...),
 		after:  append([]AfterFunc(nil), cfg.After...),
 
 		defaultTimeout:   cfg.DefaultTimeout,
 		retryMaxAttempts: maxAttempts,
-		retryDecider:     dec,
+		retryPolicy:      policy,
 		backoff:          bf,
 		bizErrDecoder:    cfg.BizErrDecoder,
 		statsHook:        cfg.StatsHook,
+
+		idempotencyHeader: cfg.IdempotencyHeader,
+		faults:            cfg.FaultInjector,
+		newLimiter:        cfg.AdaptiveLimit,
+
+		curlOnFailure: cfg.CurlOnFailure,
+		redact:        newRedactor(cfg.RedactHeaders),
+		dialTimeout:   cfg.DialTimeout,
+		proxy:         proxy,
+		unixSocket:    cfg.UnixSocket,
+		sockets:       sockets,
+		h2c:           cfg.H2C,
+		wsTransport:   wsTransport,
+		shadow:        shadow,
+
+		maxResponseBytes: cfg.MaxResponseBytes,
+		decode:           cfg.Decode,
+
+		ownsLogger: ownsLogger,
 	}, nil
 }
@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"context"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+// acquire 登记一次逻辑调用，Close 之后返回 errorx.ErrClientClosed
+func (c *Client) acquire() error {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+	if c.closed {
+		return errorx.New(errorx.ErrClientClosed, c.serviceOpts()...)
+	}
+	c.inflight.Add(1)
+	return nil
+}
+
+func (c *Client) release() {
+	c.inflight.Done()
+}
+
+// Close 优雅关闭：
+//  1. 不再接受新调用（之后的调用返回 errorx.ErrClientClosed）
+//  2. 等待进行中的调用结束，最多等到 ctx 结束
+//  3. 关闭所有 transport（普通请求 / WebSocket / 影子流量）的空闲连接
+//  4. flush 并关闭 client 自己创建的 logger
+//
+// ctx 到期时返回 ctx.Err()：仍关闭空闲连接，logger 则留到进行中的调用结束后在后台关闭，
+// 避免它们的调用记录写到已关闭的 logger；重复调用是安全的
+func (c *Client) Close(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c.closeMu.Lock()
+	already := c.closed
+	c.closed = true
+	c.closeMu.Unlock()
+	if already {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.hc.CloseIdleConnections()
+	c.wsTransport.CloseIdleConnections()
+	if c.shadow != nil {
+		c.shadow.hc.CloseIdleConnections()
+	}
+	if !c.ownsLogger {
+		return err
+	}
+	if err != nil {
+		go func() {
+			<-done
+			_ = c.logger.Close(context.Background())
+		}()
+		return err
+	}
+	return c.logger.Close(ctx)
+}
+
+// serviceOpts client 产生的 errorx.Error 带上下游服务标识
+func (c *Client) serviceOpts() []errorx.Option {
+	if c.service.Code == 0 {
+		return nil
+	}
+	return []errorx.Option{errorx.WithService(c.service)}
+}
@@ -0,0 +1,222 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+// closeRecorder 记录 Close 之后是否还有日志写入
+type closeRecorder struct {
+	nopLogger
+	mu     sync.Mutex
+	closed bool
+	writes int
+	late   int
+}
+
+func (r *closeRecorder) record() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes++
+	if r.closed {
+		r.late++
+	}
+}
+
+func (r *closeRecorder) Info(context.Context, string, any, ...any)  { r.record() }
+func (r *closeRecorder) Warn(context.Context, string, any, ...any)  { r.record() }
+func (r *closeRecorder) Error(context.Context, string, any, ...any) { r.record() }
+
+func (r *closeRecorder) Close(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+// blockingServer 请求到达后通知 started，等 release 关闭后才响应
+func blockingServer(started chan<- struct{}, release <-chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		_, _ = w.Write([]byte(`{}`))
+	}))
+}
+
+func TestCloseRejectsNewCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := c.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if _, err := c.GetJSON(ctx, "/", &out); !hasCode(err, errorx.ErrClientClosed) {
+		t.Fatalf("want ErrClientClosed, got %v", err)
+	}
+	if err := c.Close(ctx); err != nil {
+		t.Fatalf("second close: %v", err)
+	}
+}
+
+func TestCloseDrainsInflight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := blockingServer(started, release)
+	defer srv.Close()
+
+	rec := &closeRecorder{}
+	c, err := New(WithBaseURL(srv.URL), func(c *Config) { c.logger, c.ownLogger = rec, true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	callErr := make(chan error, 1)
+	go func() {
+		var out map[string]any
+		_, err := c.GetJSON(ctx, "/", &out)
+		callErr <- err
+	}()
+	<-started
+
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close(ctx) }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned before in-flight call finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-callErr; err != nil {
+		t.Fatalf("in-flight call: %v", err)
+	}
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if !rec.closed || rec.writes == 0 || rec.late != 0 {
+		t.Fatalf("closed=%v writes=%d late=%d: call record must be written before the logger is closed",
+			rec.closed, rec.writes, rec.late)
+	}
+}
+
+func TestCloseContextTimeout(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := blockingServer(started, release)
+	defer srv.Close()
+	defer close(release)
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		var out map[string]any
+		_, _ = c.GetJSON(context.Background(), "/", &out)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want DeadlineExceeded, got %v", err)
+	}
+}
+
+// ctx 到期时进行中的调用还没结束：logger 要等它们写完记录后才关闭
+func TestCloseTimeoutDefersLoggerClose(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := blockingServer(started, release)
+	defer srv.Close()
+
+	rec := &closeRecorder{}
+	c, err := New(WithBaseURL(srv.URL), func(c *Config) { c.logger, c.ownLogger = rec, true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	callErr := make(chan error, 1)
+	go func() {
+		var out map[string]any
+		_, err := c.GetJSON(context.Background(), "/", &out)
+		callErr <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := c.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want DeadlineExceeded, got %v", err)
+	}
+	close(release)
+	if err := <-callErr; err != nil {
+		t.Fatalf("in-flight call: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		rec.mu.Lock()
+		closed, writes, late := rec.closed, rec.writes, rec.late
+		rec.mu.Unlock()
+		if closed {
+			if writes == 0 || late != 0 {
+				t.Fatalf("writes=%d late=%d: call record must be written before the logger is closed", writes, late)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("logger not closed after in-flight call finished")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// WebSocket 用的 transport 也要关闭空闲连接
+func TestCloseIdleWSConnections(t *testing.T) {
+	connClosed := make(chan struct{}, 1)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateClosed {
+			connClosed <- struct{}{}
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := c.wsTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-connClosed:
+	case <-time.After(time.Second):
+		t.Fatal("idle WebSocket transport connection not closed")
+	}
+}
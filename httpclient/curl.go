@@ -0,0 +1,182 @@
+package httpclient
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// curl 命令里 body 最多保留的字节数
+const curlMaxBody = 4096
+
+// redactedValue 脱敏后的占位
+const redactedValue = "***"
+
+// 默认脱敏的请求头
+var defaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// WithCurlOnFailure 失败的 attempt 生成等价 curl 命令，记录在 CallAttempt.Curl 并写入失败日志
+func WithCurlOnFailure() Option {
+	return func(c *Config) { c.CurlOnFailure = true }
+}
+
+// WithRedactHeaders 追加需要脱敏的请求头（日志、curl 命令中显示为 ***）
+func WithRedactHeaders(h ...string) Option {
+	return func(c *Config) { c.RedactHeaders = append(c.RedactHeaders, h...) }
+}
+
+// redactor 判断 header 是否需要脱敏
+type redactor map[string]struct{}
+
+func newRedactor(extra []string) redactor {
+	r := make(redactor, len(defaultRedactHeaders)+len(extra))
+	for _, h := range defaultRedactHeaders {
+		r[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	for _, h := range extra {
+		r[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	return r
+}
+
+func (r redactor) value(key, v string) string {
+	key = http.CanonicalHeaderKey(key)
+	if _, ok := r[key]; !ok {
+		return v
+	}
+	switch key {
+	case "Cookie":
+		return redactCookies(v, "; ")
+	case "Set-Cookie":
+		// 只保留 name，属性部分一并去掉
+		name, _, _ := strings.Cut(v, ";")
+		return redactCookies(name, "")
+	}
+	return redactedValue
+}
+
+// redactCookies "a=1; b=2" → "a=***; b=***"，保留 cookie 名方便排查
+func redactCookies(v, sep string) string {
+	parts := strings.Split(v, ";")
+	for i, p := range parts {
+		name, _, _ := strings.Cut(strings.TrimSpace(p), "=")
+		parts[i] = name + "=" + redactedValue
+	}
+	if sep == "" {
+		return parts[0]
+	}
+	return strings.Join(parts, sep)
+}
+
+// curlCommand 把一次请求渲染成等价的 curl 命令
+//   - body 为 nil 且 bodyIsReader 时无法重放，只留注释
+//   - 注释统一放在命令末尾，否则 # 之后的参数在粘贴执行时都会被 shell 吞掉
+//   - timeout 对应 --max-time，dial 对应 --connect-timeout
+//   - unix socket 对应 --unix-socket，h2c 对应 --http2-prior-knowledge
+func (c *Client) curlCommand(req *http.Request, body []byte, bodyIsReader bool, timeout time.Duration) string {
+	var b strings.Builder
+	b.WriteString("curl -sS")
+	if req.Method != "" && req.Method != http.MethodGet {
+		b.WriteString(" -X ")
+		b.WriteString(req.Method)
+	}
+	b.WriteString(" ")
+	b.WriteString(shellQuote(req.URL.String()))
+
+	// jar 里的 cookie 由 http.Client 发送时才加到请求上，这里补上（值已脱敏）
+	if jar := c.httpClient(req.Context()).Jar; jar != nil && req.Header.Get("Cookie") == "" {
+		if cookies := jar.Cookies(req.URL); len(cookies) > 0 {
+			names := make([]string, len(cookies))
+			for i, ck := range cookies {
+				names[i] = ck.Name + "=" + redactedValue
+			}
+			b.WriteString(" -H ")
+			b.WriteString(shellQuote("Cookie: " + strings.Join(names, "; ")))
+		}
+	}
+
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range req.Header[k] {
+			b.WriteString(" -H ")
+			b.WriteString(shellQuote(k + ": " + c.redact.value(k, v)))
+		}
+	}
+
+	var note string
+	switch {
+	case body != nil:
+		data := string(body)
+		if len(body) > curlMaxBody {
+			data = string(body[:curlMaxBody])
+		}
+		b.WriteString(" --data-raw ")
+		b.WriteString(shellQuote(data))
+		if len(body) > curlMaxBody {
+			note = "body truncated, " + strconv.Itoa(len(body)) + " bytes"
+		}
+	case bodyIsReader:
+		note = "body from io.Reader omitted"
+	}
+
+	if c.proxy != nil {
+		if u, err := c.proxy(req); err == nil && u != nil {
+			b.WriteString(" --proxy ")
+			b.WriteString(shellQuote(redactProxy(u)))
+			if u.User != nil {
+				b.WriteString(" --proxy-user ")
+				b.WriteString(shellQuote(redactedValue))
+			}
+		}
+	}
+	if path := c.socketPath(req.URL.Hostname()); path != "" {
+		b.WriteString(" --unix-socket ")
+		b.WriteString(shellQuote(path))
+	}
+	if c.h2c && req.URL.Scheme == "http" {
+		b.WriteString(" --http2-prior-knowledge")
+	}
+	if c.dialTimeout > 0 {
+		b.WriteString(" --connect-timeout ")
+		b.WriteString(formatSeconds(c.dialTimeout))
+	}
+	if timeout > 0 {
+		b.WriteString(" --max-time ")
+		b.WriteString(formatSeconds(timeout))
+	}
+	if note != "" {
+		b.WriteString(" # ")
+		b.WriteString(note)
+	}
+	return b.String()
+}
+
+// socketPath 与拨号时的选择一致：unix:// base URL 的 socket 优先，否则用 Config.UnixSocket
+func (c *Client) socketPath(host string) string {
+	if path := c.sockets[host]; path != "" {
+		return path
+	}
+	return c.unixSocket
+}
+
+// shellQuote 用单引号包裹，内部的单引号先闭合、转义再重新打开
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,117 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+// 默认最大响应体 64MB
+const defaultMaxResponseBytes = 64 << 20
+
+// 解码失败时错误里保留的响应体片段长度
+const decodeSnippetBytes = 256
+
+// DecodeOptions 响应体 JSON 解码选项
+type DecodeOptions struct {
+	DisallowUnknownFields bool // 出现结构体里没有的字段时报错
+	UseNumber             bool // 数字解码为 json.Number，避免大整数丢精度
+	AllowEmpty            bool // 空 body / 204 不解码，也不报错
+}
+
+// WithMaxResponseBytes client 默认的最大响应体（仅对需要读入内存的 *[]byte / JSON 生效），<0 表示不限制
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Config) { c.MaxResponseBytes = n }
+}
+
+// WithDecodeOptions client 默认的解码选项
+func WithDecodeOptions(d DecodeOptions) Option {
+	return func(c *Config) { c.Decode = d }
+}
+
+// WithRequestMaxResponseBytes 单次请求覆盖最大响应体，<0 表示不限制
+func WithRequestMaxResponseBytes(n int64) RequestOption {
+	return func(r *Request) { r.MaxResponseBytes = n }
+}
+
+// WithRequestDecodeOptions 单次请求覆盖解码选项
+func WithRequestDecodeOptions(d DecodeOptions) RequestOption {
+	return func(r *Request) { r.Decode = &d }
+}
+
+// readBody 读完响应体，超过 limit 时返回 errorx.ErrResponseTooLarge
+func (c *Client) readBody(resp *http.Response, reqCfg *Request) ([]byte, error) {
+	limit := c.maxResponseBytes
+	if reqCfg.MaxResponseBytes != 0 {
+		limit = reqCfg.MaxResponseBytes
+	}
+	if limit < 0 {
+		return io.ReadAll(resp.Body)
+	}
+	if resp.ContentLength > limit {
+		return nil, c.tooLargeError(resp.ContentLength, limit)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, c.tooLargeError(-1, limit)
+	}
+	return data, nil
+}
+
+func (c *Client) tooLargeError(size, limit int64) error {
+	msg := fmt.Sprintf("response body exceeds %d bytes", limit)
+	if size >= 0 {
+		msg = fmt.Sprintf("response body %d bytes exceeds %d bytes", size, limit)
+	}
+	opts := append([]errorx.Option{
+		errorx.WithMessage(msg),
+		errorx.WithField("limit", limit),
+	}, c.serviceOpts()...)
+	return errorx.New(errorx.ErrResponseTooLarge, opts...)
+}
+
+// decodeBody 按选项把 JSON 解码到 out，失败时错误里带上一段响应体
+func (c *Client) decodeBody(resp *http.Response, data []byte, out any, reqCfg *Request) error {
+	opts := c.decode
+	if reqCfg.Decode != nil {
+		opts = *reqCfg.Decode
+	}
+	if opts.AllowEmpty && (resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(data)) == 0) {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if opts.UseNumber {
+		dec.UseNumber()
+	}
+	err := dec.Decode(out)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		// 顶层值之后还有数据，例如 `{"a":1}{"b":2}` 或 `{"a":1} xx`
+		err = errors.New("invalid data after top-level value")
+	}
+	if err != nil {
+		snippet := data
+		if len(snippet) > decodeSnippetBytes {
+			snippet = snippet[:decodeSnippetBytes]
+		}
+		eopts := append([]errorx.Option{
+			errorx.WithMessage(fmt.Sprintf("decode %T: %v", out, err)),
+			errorx.WithCause(err),
+			errorx.WithField("snippet", string(snippet)),
+			errorx.WithField("body_size", len(data)),
+		}, c.serviceOpts()...)
+		return errorx.New(errorx.ErrResponseDecode, eopts...)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+func TestDoResponseLimitAndDecode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/big":
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/trailing":
+			_, _ = w.Write([]byte(`{"id":1}{"id":2}`))
+		case "/garbage":
+			_, _ = w.Write([]byte(`{"id":1} xx`))
+		case "/space":
+			_, _ = w.Write([]byte("{\"id\":1}\n\n"))
+		default:
+			_, _ = w.Write([]byte(`{"id":1,"extra":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL), WithMaxResponseBytes(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var raw []byte
+	_, err = c.GetJSON(ctx, "/big", &raw)
+	if !hasCode(err, errorx.ErrResponseTooLarge) {
+		t.Fatalf("want ErrResponseTooLarge, got %v", err)
+	}
+	_, err = c.GetJSON(ctx, "/big", &raw, WithRequestMaxResponseBytes(-1))
+	if err != nil || len(raw) != 100 {
+		t.Fatalf("override: err=%v len=%d", err, len(raw))
+	}
+
+	var out struct {
+		ID int `json:"id"`
+	}
+	strict := DecodeOptions{DisallowUnknownFields: true}
+	_, err = c.GetJSON(ctx, "/obj", &out, WithRequestMaxResponseBytes(1024), WithRequestDecodeOptions(strict))
+	if !hasCode(err, errorx.ErrResponseDecode) {
+		t.Fatalf("want ErrResponseDecode, got %v", err)
+	}
+
+	for _, path := range []string{"/trailing", "/garbage"} {
+		_, err = c.GetJSON(ctx, path, &out, WithRequestMaxResponseBytes(1024))
+		if !hasCode(err, errorx.ErrResponseDecode) {
+			t.Fatalf("%s: want ErrResponseDecode, got %v", path, err)
+		}
+	}
+	_, err = c.GetJSON(ctx, "/space", &out, WithRequestMaxResponseBytes(1024))
+	if err != nil || out.ID != 1 {
+		t.Fatalf("trailing whitespace: err=%v out=%+v", err, out)
+	}
+
+	_, err = c.GetJSON(ctx, "/empty", &out, WithRequestDecodeOptions(DecodeOptions{AllowEmpty: true}))
+	if err != nil {
+		t.Fatalf("204 with AllowEmpty: %v", err)
+	}
+}
+
+func hasCode(err error, code errorx.CodeEntry) bool {
+	e, ok := errorx.From(err)
+	return ok && e.Code.Code == code.Code
+}
@@ -0,0 +1,563 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+	"golang.org/x/sync/singleflight"
+)
+
+// Resolver 解析 host，返回地址和可缓存时间；ttl < 0 表示未知（使用 DNSCacheConfig.DefaultTTL）
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) (addrs []string, ttl time.Duration, err error)
+}
+
+// DNSCacheConfig DNS 缓存配置
+type DNSCacheConfig struct {
+	Resolver      Resolver      // nil 使用系统 resolver（拿不到 TTL，按 DefaultTTL 缓存）
+	DefaultTTL    time.Duration // TTL 未知时的缓存时间，默认 30s
+	MinTTL        time.Duration // TTL 下限，避免 TTL=0 的记录每次都查
+	MaxTTL        time.Duration // TTL 上限，默认 5m
+	StaleTTL      time.Duration // 解析失败时过期记录还能继续使用的时间，0 表示不使用
+	LookupTimeout time.Duration // 单次解析超时，默认 5s
+}
+
+// WithDNSCache 开启 DNS 缓存（按记录 TTL 过期，同一 host 并发解析合并为一次）
+func WithDNSCache(cfg DNSCacheConfig) Option {
+	return func(c *Config) { c.DNSCache = &cfg }
+}
+
+// systemResolver 系统 resolver，不知道 TTL
+type systemResolver struct{}
+
+func (systemResolver) LookupHost(ctx context.Context, host string) ([]string, time.Duration, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	return addrs, -1, err
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+type dnsCache struct {
+	cfg       DNSCacheConfig
+	mu        sync.Mutex
+	entries   map[string]dnsEntry
+	nextSweep time.Time // 下次清理过期记录的时间
+	group     singleflight.Group
+}
+
+func newDNSCache(cfg DNSCacheConfig) *dnsCache {
+	if cfg.Resolver == nil {
+		cfg.Resolver = systemResolver{}
+	}
+	if cfg.DefaultTTL <= 0 {
+		cfg.DefaultTTL = 30 * time.Second
+	}
+	if cfg.MaxTTL <= 0 {
+		cfg.MaxTTL = 5 * time.Minute
+	}
+	if cfg.LookupTimeout <= 0 {
+		cfg.LookupTimeout = 5 * time.Second
+	}
+	return &dnsCache{cfg: cfg, entries: make(map[string]dnsEntry)}
+}
+
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[host]
+	if ok && !c.usable(e, now) {
+		delete(c.entries, host)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.addrs, nil
+	}
+
+	v, err, _ := c.group.Do(host, func() (any, error) {
+		// 合并后的查询不受某一个调用方取消的影响
+		lctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cfg.LookupTimeout)
+		defer cancel()
+		addrs, ttl, err := c.cfg.Resolver.LookupHost(lctx, host)
+		if err == nil && len(addrs) == 0 {
+			err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ttl < 0 {
+			ttl = c.cfg.DefaultTTL
+		}
+		ttl = min(max(ttl, c.cfg.MinTTL), c.cfg.MaxTTL)
+		now := time.Now()
+		c.mu.Lock()
+		c.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(ttl)}
+		c.sweep(now)
+		c.mu.Unlock()
+		return addrs, nil
+	})
+	if err != nil {
+		if ok {
+			// 能走到这里说明记录已过期，usable 保证仍在 StaleTTL 内
+			return e.addrs, nil
+		}
+		return nil, err
+	}
+	return v.([]string), nil
+}
+
+// usable 记录未过期，或过期后仍在 StaleTTL 内（解析失败时兜底）
+func (c *dnsCache) usable(e dnsEntry, now time.Time) bool {
+	return now.Before(e.expires.Add(c.cfg.StaleTTL))
+}
+
+// sweep 每隔 MaxTTL 清理一次不再可用的记录，避免只解析过一次的 host 一直留在缓存里；调用方持有 mu
+func (c *dnsCache) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+	c.nextSweep = now.Add(c.cfg.MaxTTL)
+	for host, e := range c.entries {
+		if !c.usable(e, now) {
+			delete(c.entries, host)
+		}
+	}
+}
+
+// dialer 解析走缓存，再按 happy eyeballs 拨号到具体 IP
+func (c *dnsCache) dialer(next DialFunc, fallbackDelay time.Duration) DialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return next(ctx, network, addr)
+		}
+		addrs, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		return dialHappyEyeballs(ctx, next, network, filterFamily(network, addrs), port, fallbackDelay)
+	}
+}
+
+// filterFamily tcp4 / tcp6 只保留对应地址族
+func filterFamily(network string, addrs []string) []string {
+	if network != "tcp4" && network != "tcp6" {
+		return addrs
+	}
+	out := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		ip := net.ParseIP(a)
+		if ip != nil && (ip.To4() != nil) == (network == "tcp4") {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// dialHappyEyeballs 按第一个地址的地址族分成主 / 备两组：主组串行拨号，
+// delay 后（或主组失败时）并行开始拨备组，先成功的胜出（RFC 6555）
+func dialHappyEyeballs(ctx context.Context, dial DialFunc, network string, addrs []string, port string, delay time.Duration) (net.Conn, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("httpclient: no address to dial")
+	}
+	var primaries, fallbacks []string
+	isV4 := func(a string) bool { ip := net.ParseIP(a); return ip != nil && ip.To4() != nil }
+	for _, a := range addrs {
+		if isV4(a) == isV4(addrs[0]) {
+			primaries = append(primaries, a)
+		} else {
+			fallbacks = append(fallbacks, a)
+		}
+	}
+	if len(fallbacks) == 0 || delay < 0 {
+		return dialSerial(ctx, dial, network, addrs, port)
+	}
+	if delay == 0 {
+		delay = 300 * time.Millisecond
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result, 2)
+	race := func(group []string) {
+		conn, err := dialSerial(ctx, dial, network, group, port)
+		results <- result{conn, err}
+	}
+	go race(primaries)
+	pending, fallbackStarted := 1, false
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go race(fallbacks)
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if pending > 0 {
+					// 另一组晚到的连接直接关掉
+					go func() {
+						if r := <-results; r.conn != nil {
+							_ = r.conn.Close()
+						}
+					}()
+				}
+				return r.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				pending++
+				go race(fallbacks)
+				continue
+			}
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+func dialSerial(ctx context.Context, dial DialFunc, network string, addrs []string, port string) (net.Conn, error) {
+	var firstErr error
+	for _, a := range addrs {
+		if err := ctx.Err(); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			break
+		}
+		conn, err := dial(ctx, network, net.JoinHostPort(a, port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// -------------------- 带 TTL 的 DNS resolver --------------------
+
+// dnsResolver 直接向 DNS 服务器查询 A / AAAA，返回记录里的最小 TTL；
+// 短域名按 resolv.conf 的 search / ndots 展开，UDP 响应被截断时改用 TCP 重查
+type dnsResolver struct {
+	server  string
+	search  []string // 不带结尾的 "."
+	ndots   int
+	timeout time.Duration // 单次查询的超时，<=0 使用 defaultDNSTimeout；ctx 的 deadline 更早时以 ctx 为准
+}
+
+// defaultDNSTimeout 单次查询的默认超时，和 resolv.conf 的默认 timeout 一致
+const defaultDNSTimeout = 5 * time.Second
+
+// NewDNSResolver server 为 "host:port"，空时使用 /etc/resolv.conf 里的第一个 nameserver；
+// search / ndots 总是取自 /etc/resolv.conf
+func NewDNSResolver(server string) Resolver {
+	conf := resolvConf{nameserver: "127.0.0.1:53", ndots: 1}
+	if f, err := os.Open("/etc/resolv.conf"); err == nil {
+		conf = parseResolvConf(f)
+		_ = f.Close()
+	}
+	if server == "" {
+		server = conf.nameserver
+	}
+	return &dnsResolver{server: server, search: conf.search, ndots: conf.ndots}
+}
+
+type resolvConf struct {
+	nameserver string
+	search     []string
+	ndots      int
+}
+
+// parseResolvConf 只取第一个 nameserver、最后一条 search / domain 和 options ndots
+func parseResolvConf(r io.Reader) resolvConf {
+	conf := resolvConf{ndots: 1}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			if conf.nameserver == "" {
+				conf.nameserver = net.JoinHostPort(fields[1], "53")
+			}
+		case "domain":
+			conf.search = []string{strings.TrimSuffix(fields[1], ".")}
+		case "search":
+			conf.search = conf.search[:0:0]
+			for _, d := range fields[1:] {
+				conf.search = append(conf.search, strings.TrimSuffix(d, "."))
+			}
+		case "options":
+			for _, opt := range fields[1:] {
+				if v, ok := strings.CutPrefix(opt, "ndots:"); ok {
+					if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+						conf.ndots = min(n, 15)
+					}
+				}
+			}
+		}
+	}
+	if conf.nameserver == "" {
+		conf.nameserver = "127.0.0.1:53"
+	}
+	return conf
+}
+
+// nameList 与系统 resolver 一致：以 "." 结尾的只查自身；
+// 点数 >= ndots 时先查原名再查 search 展开，否则先 search 展开最后查原名
+func (r *dnsResolver) nameList(host string) []string {
+	if strings.HasSuffix(host, ".") {
+		return []string{host}
+	}
+	hasNdots := strings.Count(host, ".") >= r.ndots
+	names := make([]string, 0, len(r.search)+1)
+	if hasNdots {
+		names = append(names, host+".")
+	}
+	for _, d := range r.search {
+		names = append(names, host+"."+d+".")
+	}
+	if !hasNdots {
+		names = append(names, host+".")
+	}
+	return names
+}
+
+func (r *dnsResolver) LookupHost(ctx context.Context, host string) ([]string, time.Duration, error) {
+	var lastErr error
+	for _, name := range r.nameList(host) {
+		addrs, ttl, err := r.lookupName(ctx, name)
+		if err == nil && len(addrs) > 0 {
+			return addrs, ttl, nil
+		}
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		// 不存在的展开名继续试下一个，其他错误留着，都失败时返回
+		var de *net.DNSError
+		if err != nil && (lastErr == nil || !errors.As(err, &de) || !de.IsNotFound) {
+			lastErr = err
+		}
+	}
+	var de *net.DNSError
+	if lastErr == nil || (errors.As(lastErr, &de) && de.IsNotFound) {
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: r.server, IsNotFound: true}
+	}
+	return nil, 0, lastErr
+}
+
+// lookupName 并发查询 name 的 A / AAAA
+func (r *dnsResolver) lookupName(ctx context.Context, name string) ([]string, time.Duration, error) {
+	type result struct {
+		addrs []string
+		ttl   time.Duration
+		err   error
+	}
+	types := []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	results := make([]result, len(types))
+	var wg sync.WaitGroup
+	for i, t := range types {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			addrs, ttl, err := r.query(ctx, name, t)
+			results[i] = result{addrs, ttl, err}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		addrs []string
+		ttl   time.Duration = -1
+		errs  []error
+	)
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, res.err)
+			continue
+		}
+		if len(res.addrs) > 0 {
+			addrs = append(addrs, res.addrs...)
+			if ttl < 0 || res.ttl < ttl {
+				ttl = res.ttl
+			}
+		}
+	}
+	if len(addrs) == 0 && len(errs) > 0 {
+		return nil, 0, errors.Join(errs...)
+	}
+	return addrs, ttl, nil
+}
+
+// query 查询一个完整域名（以 "." 结尾）
+func (r *dnsResolver) query(ctx context.Context, fqdn string, t dnsmessage.Type) ([]string, time.Duration, error) {
+	name, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return nil, 0, err
+	}
+	id := uint16(rand.Uint32())
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: t, Class: dnsmessage.ClassINET}},
+	}
+	req, err := msg.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	p, h, err := r.exchange(ctx, "udp", req, id)
+	if err == nil && h.Truncated {
+		// 答案超出 UDP 报文大小，完整结果只能走 TCP 拿
+		p, h, err = r.exchange(ctx, "tcp", req, id)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if h.RCode == dnsmessage.RCodeNameError {
+		return nil, 0, &net.DNSError{Err: "no such host", Name: fqdn, Server: r.server, IsNotFound: true}
+	}
+	if h.RCode != dnsmessage.RCodeSuccess {
+		return nil, 0, &net.DNSError{Err: h.RCode.String(), Name: fqdn, Server: r.server}
+	}
+	return parseAnswers(p)
+}
+
+// exchange 发出查询并等到 ID 匹配的响应；TCP 报文带 2 字节长度前缀
+func (r *dnsResolver) exchange(ctx context.Context, network string, req []byte, id uint16) (*dnsmessage.Parser, dnsmessage.Header, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, r.server)
+	if err != nil {
+		return nil, dnsmessage.Header{}, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	// 总是设置 deadline：ctx 没有 deadline 时 UDP 查询丢包会一直阻塞
+	timeout := r.timeout
+	if timeout <= 0 {
+		timeout = defaultDNSTimeout
+	}
+	dl := time.Now().Add(timeout)
+	if ctxDl, ok := ctx.Deadline(); ok && ctxDl.Before(dl) {
+		dl = ctxDl
+	}
+	_ = conn.SetDeadline(dl)
+	// ctx 被取消时让阻塞的读写立即返回
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if network == "tcp" {
+		out := make([]byte, 2+len(req))
+		binary.BigEndian.PutUint16(out, uint16(len(req)))
+		copy(out[2:], req)
+		if _, err := conn.Write(out); err != nil {
+			return nil, dnsmessage.Header{}, err
+		}
+	} else if _, err := conn.Write(req); err != nil {
+		return nil, dnsmessage.Header{}, err
+	}
+
+	buf := make([]byte, 1232)
+	for {
+		var n int
+		if network == "tcp" {
+			var l [2]byte
+			if _, err := io.ReadFull(conn, l[:]); err != nil {
+				return nil, dnsmessage.Header{}, err
+			}
+			n = int(binary.BigEndian.Uint16(l[:]))
+			if n > len(buf) {
+				buf = make([]byte, n)
+			}
+			if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+				return nil, dnsmessage.Header{}, err
+			}
+		} else if n, err = conn.Read(buf); err != nil {
+			return nil, dnsmessage.Header{}, err
+		}
+		p := new(dnsmessage.Parser)
+		h, err := p.Start(buf[:n])
+		if err != nil || h.ID != id || !h.Response {
+			// 不是这次查询的响应，继续等
+			continue
+		}
+		return p, h, nil
+	}
+}
+
+func parseAnswers(p *dnsmessage.Parser) ([]string, time.Duration, error) {
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, 0, err
+	}
+	var (
+		addrs []string
+		ttl   time.Duration = -1
+	)
+	for {
+		ah, err := p.AnswerHeader()
+		if errors.Is(err, dnsmessage.ErrSectionDone) {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		var ip net.IP
+		switch ah.Type {
+		case dnsmessage.TypeA:
+			a, err := p.AResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ip = a.A[:]
+		case dnsmessage.TypeAAAA:
+			a, err := p.AAAAResource()
+			if err != nil {
+				return nil, 0, err
+			}
+			ip = a.AAAA[:]
+		default:
+			// CNAME 等：递归服务器会把最终的 A / AAAA 一起放在 answer 里
+			if err := p.SkipAnswer(); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+		addrs = append(addrs, ip.String())
+		if d := time.Duration(ah.TTL) * time.Second; ttl < 0 || d < ttl {
+			ttl = d
+		}
+	}
+	return addrs, ttl, nil
+}
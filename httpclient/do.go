@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -26,38 +28,17 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 		Method: reqCfg.Method,
 		Query:  reqCfg.Query.Encode(),
 	}
-	defer func() {
-		logMap := map[string]interface{}{
-			logx.Method:      stats.Method,
-			logx.URL:         stats.URL,
-			logx.Path:        stats.Path,
-			logx.Query:       stats.Query,
-			logx.Body:        stats.Body,
-			"body_size":      stats.BodySize,
-			logx.Attempts:    stats.Attempts,
-			logx.MaxAttempts: stats.MaxAttempts,
-			logx.Response:    stats.Response,
-		}
-		ctx := stats.ctx
-		if stats.Attempts >= 1 {
-			v := stats.AttemptsLog[stats.Attempts-1]
-			ctx = v.ctx
-			logMap[logx.Cost] = v.Cost / time.Millisecond
-		}
-		if stats.Err != nil {
-			logMap[logx.Err] = stats.Err.Error()
-		}
-
-		if errorx.IsSuccess(stats.Err) {
-			c.logger.Info(ctx, logx.TagHttpFailure, logMap)
-		} else {
-			c.logger.Warn(ctx, logx.TagHttpSuccess, logMap)
-		}
-	}()
-
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if err := c.acquire(); err != nil {
+		stats.Err = err
+		c.report(stats)
+		return nil, err
+	}
+	// release 要在 report 之后：否则 Close 可能在日志写完前关闭自有 logger
+	defer c.release()
+	defer c.report(stats)
 
 	// ---------- per-request timeout ----------
 	timeout := reqCfg.Timeout
@@ -78,7 +59,7 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 		bodyReader   io.Reader
 		bodyIsReader bool
 	)
-	headers := cloneHeader(reqCfg.Headers)
+	headers := c.sessionFor(ctx).mergeHeaders(cloneHeader(reqCfg.Headers))
 
 	switch v := reqCfg.Body.(type) {
 	case nil:
@@ -100,6 +81,16 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 		}
 	}
 
+	// ---------- 幂等键：一次逻辑调用一个 key，所有重试共用 ----------
+	if header, key := c.idempotencyKey(reqCfg, headers); key != "" {
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set(header, key)
+		stats.IdempotencyKey = key
+	}
+	ctx = withIdempotencyKey(ctx, stats.IdempotencyKey)
+
 	// ---------- 重试次数 ----------
 	attempts := c.retryMaxAttempts
 	if bodyIsReader {
@@ -128,12 +119,16 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 			Attempt: attempt + 1,
 		}
 		lastResp, isBreak, lastErr = func() (aResp *http.Response, isBreak bool, aErr error) {
-			ctx, _ := tracex.StartSpan(ctx, "http")
+			spanName := "http"
+			if reqCfg.Operation != "" {
+				spanName = reqCfg.Operation
+			}
+			ctx, _ := tracex.StartSpan(ctx, spanName)
 			defer func() {
-				tracex.EndSpan(ctx, nil)
+				tracex.EndSpan(ctx, aErr)
 				aAttempt.ctx = ctx
 			}()
-			ctx, timeoutCancel := context.WithTimeout(ctx, timeout)
+			ctx, timeoutCancel := context.WithTimeoutCause(ctx, timeout, errAttemptTimeout)
 			defer timeoutCancel()
 
 			// 每次重试重建 body reader
@@ -152,6 +147,10 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 
 			if stats.Path == "" && httpReq.URL != nil {
 				stats.Path = httpReq.URL.Path
+				if reqCfg.Operation != "" {
+					stats.Path = reqCfg.Operation
+				}
+				stats.Host = httpReq.URL.Host
 			}
 			if stats.Query == "" && httpReq.URL != nil {
 				stats.Query = httpReq.URL.RawQuery
@@ -163,7 +162,7 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 			}
 
 			attemptStart := time.Now()
-			resp, err := c.hc.Do(httpReq)
+			resp, err := c.send(httpReq, &aAttempt)
 			aAttempt.Cost = time.Since(attemptStart)
 
 			// after hook
@@ -173,15 +172,17 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 
 			if resp != nil {
 				aAttempt.Status = resp.StatusCode
-				if err == nil && resp.StatusCode != 200 {
-					err = errorx.New(errorx.CodeEntry{
-						Code:    resp.StatusCode,
-						Message: resp.Status,
-					})
+				stats.Status = resp.StatusCode
+				if err == nil && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+					err = c.statusError(resp)
 				}
 			}
+			aAttempt.Err = err
+			if err != nil && c.curlOnFailure {
+				aAttempt.Curl = c.curlCommand(httpReq, bodyBytes, bodyIsReader, timeout)
+			}
 			// 是否需要重试
-			aAttempt.WillRetry = attempt < attempts-1 && c.retryDecider(resp, err)
+			aAttempt.WillRetry = attempt < attempts-1 && c.retryPolicy(httpReq, resp, err)
 			if !aAttempt.WillRetry {
 				return resp, true, err
 			}
@@ -212,20 +213,29 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 	// ---------- 填充最终统计 ----------
 	stats.Cost = time.Since(begin)
 	stats.Attempts = len(stats.AttemptsLog)
+	if lastErr != nil {
+		lastErr = c.callError(stats, lastErr)
+	}
 	stats.Err = lastErr
 
-	// ---------- 整理返回 ----------
-	if lastErr != nil && lastResp == nil {
-		return nil, lastErr
+	// ---------- 影子流量：Do 返回后异步重放，不影响主请求 ----------
+	var primaryBody []byte
+	if c.shadow != nil && !bodyIsReader {
+		defer func() {
+			call := shadowCall{method: reqCfg.Method, url: u, headers: headers, body: bodyBytes}
+			c.mirror(ctx, call, stats.Status, primaryBody)
+		}()
 	}
+
+	// ---------- 整理返回 ----------
 	resp := lastResp
 	if resp == nil {
 		return nil, lastErr
 	}
 
-	// 调用方自己处理 body
+	// 调用方自己处理 body（非 200 时同时返回错误，body 仍需调用方 Close）
 	if respBody == nil {
-		return resp, nil
+		return resp, lastErr
 	}
 	defer func() {
 		_ = resp.Body.Close()
@@ -233,16 +243,19 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 
 	// io.Writer：流式复制
 	if w, ok := respBody.(io.Writer); ok {
-		_, err := io.Copy(w, resp.Body)
-		stats.Err = err
-		return resp, err
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			stats.Err = err
+			return resp, err
+		}
+		return resp, lastErr
 	}
-	// 读完
-	data, err := io.ReadAll(resp.Body)
+	// 读完（受最大响应体限制）
+	data, err := c.readBody(resp, reqCfg)
 	if err != nil {
 		stats.Err = err
 		return resp, err
 	}
+	primaryBody = data
 
 	// 业务错误解析
 	if c.bizErrDecoder != nil {
@@ -256,10 +269,16 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 	if p, ok := respBody.(*[]byte); ok {
 		*p = data
 		stats.Response = string(data)
-		return resp, nil
+		return resp, lastErr
+	}
+	// 非 2xx：尽量解析错误响应体，返回状态错误
+	if lastErr != nil {
+		_ = json.Unmarshal(data, respBody)
+		stats.Response = string(data)
+		return resp, lastErr
 	}
 	// 默认 JSON
-	if err := json.Unmarshal(data, respBody); err != nil {
+	if err := c.decodeBody(resp, data, respBody, reqCfg); err != nil {
 		stats.Err = err
 		return resp, err
 	}
@@ -267,6 +286,149 @@ func (c *Client) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.R
 	return resp, nil
 }
 
+// statusError 非 2xx 响应转成 errorx.Error，code 为 HTTP 状态码
+func (c *Client) statusError(resp *http.Response) error {
+	return errorx.New(errorx.CodeEntry{
+		Code:    resp.StatusCode,
+		Message: resp.Status,
+	}, c.serviceOpts()...)
+}
+
+// callError 把所有 attempt 的错误汇总成一个 *errorx.Error：
+//   - Code / Type 沿用最后一个错误（例如 HTTP 状态码），否则为 errorx.ErrHttpCall
+//   - Cause 为 errors.Join 后的各次错误，errors.Is / As 对任意一次的错误都有效
+//   - Fields 带上 attempts / status / host / cost_ms
+func (c *Client) callError(stats *CallStats, lastErr error) error {
+	errs := make([]error, 0, len(stats.AttemptsLog)+1)
+	for _, a := range stats.AttemptsLog {
+		if a.Err != nil {
+			errs = append(errs, fmt.Errorf("attempt %d: %w", a.Attempt, a.Err))
+		}
+	}
+	if n := len(stats.AttemptsLog); n == 0 || stats.AttemptsLog[n-1].Err != lastErr {
+		// 例如退避等待时 ctx 被取消
+		errs = append(errs, lastErr)
+	}
+
+	code, typ, service := errorx.ErrHttpCall, errorx.ErrTypeSys, c.service
+	if e, ok := errorx.From(lastErr); ok {
+		code, typ = e.Code, e.Type
+		if e.Service.Code != errorx.ServiceDefault.Code {
+			service = e.Service
+		}
+	}
+	opts := []errorx.Option{
+		errorx.WithType(typ),
+		errorx.WithMessage(fmt.Sprintf("%s %s failed after %d attempt(s)", stats.Method, stats.Host, stats.Attempts)),
+		errorx.WithCause(errors.Join(errs...)),
+		errorx.WithFields(map[string]any{
+			logx.Attempts: stats.Attempts,
+			"status":      stats.Status,
+			"host":        stats.Host,
+			"cost_ms":     stats.Cost.Milliseconds(),
+		}),
+	}
+	if service.Code != 0 {
+		opts = append(opts, errorx.WithService(service))
+	}
+	return errorx.New(code, opts...)
+}
+
+// send 发出单次请求：先过自适应并发限制，再按故障注入规则执行
+func (c *Client) send(httpReq *http.Request, a *CallAttempt) (*http.Response, error) {
+	l := c.limiterFor(httpReq.URL.Host)
+	if l == nil {
+		return c.roundTrip(httpReq, a)
+	}
+	release, ok := l.Acquire()
+	a.Limit = l.Limit()
+	if !ok {
+		return nil, limitedError(httpReq.URL.Host, a.Limit, c.service)
+	}
+	start := time.Now()
+	resp, err := c.roundTrip(httpReq, a)
+	release(time.Since(start), isOverload(httpReq.Context(), resp, err))
+	return resp, err
+}
+
+// roundTrip 命中故障注入规则时按规则执行，否则直接发请求
+func (c *Client) roundTrip(httpReq *http.Request, a *CallAttempt) (*http.Response, error) {
+	a.Proxy = c.proxyOf(httpReq)
+	rule, ok := c.faults.match(httpReq)
+	if !ok {
+		return c.httpClient(httpReq.Context()).Do(httpReq)
+	}
+	a.Fault = rule.faultDesc()
+	resp, handled, err := rule.inject(httpReq.Context(), httpReq)
+	if handled {
+		return resp, err
+	}
+	resp, err = c.httpClient(httpReq.Context()).Do(httpReq)
+	if err == nil && rule.TruncateBody > 0 {
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remain: rule.TruncateBody}
+	}
+	return resp, err
+}
+
+// report 调用结束后打日志并触发 StatsHook
+func (c *Client) report(stats *CallStats) {
+	logMap := map[string]interface{}{
+		logx.Method:      stats.Method,
+		logx.URL:         stats.URL,
+		logx.Path:        stats.Path,
+		logx.Query:       stats.Query,
+		logx.Body:        stats.Body,
+		"body_size":      stats.BodySize,
+		logx.Attempts:    stats.Attempts,
+		logx.MaxAttempts: stats.MaxAttempts,
+		logx.Response:    stats.Response,
+	}
+	if stats.IdempotencyKey != "" {
+		logMap[logx.IdempotencyKey] = stats.IdempotencyKey
+	}
+	ctx := stats.ctx
+	if stats.Attempts >= 1 {
+		v := stats.AttemptsLog[stats.Attempts-1]
+		ctx = v.ctx
+		logMap[logx.Cost] = v.Cost / time.Millisecond
+		if v.Limit > 0 {
+			logMap[logx.Limit] = v.Limit
+		}
+		if v.Proxy != "" {
+			logMap[logx.Proxy] = v.Proxy
+		}
+	}
+	if stats.Err != nil {
+		logMap[logx.Err] = stats.Err.Error()
+	}
+	var faults []string
+	for _, a := range stats.AttemptsLog {
+		if a.Fault != "" {
+			faults = append(faults, fmt.Sprintf("#%d %s", a.Attempt, a.Fault))
+		}
+	}
+	if len(faults) > 0 {
+		logMap[logx.Fault] = faults
+	}
+	if stats.Err != nil {
+		for i := len(stats.AttemptsLog) - 1; i >= 0; i-- {
+			if curl := stats.AttemptsLog[i].Curl; curl != "" {
+				logMap[logx.Curl] = curl
+				break
+			}
+		}
+	}
+
+	if stats.Err == nil || errorx.IsSuccess(stats.Err) {
+		c.logger.Info(ctx, logx.TagHttpSuccess, logMap)
+	} else {
+		c.logger.Warn(ctx, logx.TagHttpFailure, logMap)
+	}
+	if c.statsHook != nil {
+		c.statsHook(ctx, stats)
+	}
+}
+
 // -------- 便捷方法 --------
 
 func (c *Client) GetJSON(ctx context.Context, path string, out any, opts ...RequestOption) (*http.Response, error) {
@@ -0,0 +1,151 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/logx"
+)
+
+// flakyServer 第一次请求直接断开连接，之后返回 503
+func flakyServer() *httptest.Server {
+	var n atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Add(1) == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"msg":"busy"}`))
+	}))
+}
+
+func TestDoJoinsAttemptErrors(t *testing.T) {
+	srv := flakyServer()
+	defer srv.Close()
+
+	var stats *CallStats
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL),
+		WithRetry(3, nil, func(int) time.Duration { return 0 }),
+		WithStatsHook(func(_ context.Context, s *CallStats) { stats = s }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	var out map[string]any
+	_, err = c.Do(context.Background(), &Request{Method: http.MethodGet, Path: "/"}, &out)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if stats == nil || stats.Attempts != 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	for _, a := range stats.AttemptsLog {
+		if a.Err == nil || !errors.Is(err, a.Err) {
+			t.Errorf("attempt %d: errors.Is(%v) = false", a.Attempt, a.Err)
+		}
+	}
+
+	// 顶层是聚合后的 errorx.Error，code 沿用最后一次的状态码
+	e, ok := errorx.From(err)
+	if !ok || e.Code.Code != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v", err)
+	}
+	// 第一次的网络错误
+	var ue *url.Error
+	if !errors.As(err, &ue) {
+		t.Errorf("errors.As(*url.Error) = false: %v", err)
+	}
+	// 后两次的状态错误
+	var status int
+	for _, a := range stats.AttemptsLog[1:] {
+		var se *errorx.Error
+		if errors.As(a.Err, &se) && se.Code.Code == http.StatusServiceUnavailable {
+			status++
+		}
+	}
+	if status != 2 {
+		t.Errorf("status errors = %d, want 2", status)
+	}
+	if out["msg"] != "busy" {
+		t.Errorf("out = %v, want error body decoded", out)
+	}
+}
+
+func TestDoNilRespBodyReturnsStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("missing"))
+	}))
+	defer srv.Close()
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	resp, err := c.Do(context.Background(), &Request{Method: http.MethodGet, Path: "/"}, nil)
+	if resp == nil {
+		t.Fatal("want response")
+	}
+	defer resp.Body.Close()
+	if !hasCode(err, errorx.CodeEntry{Code: http.StatusNotFound}) {
+		t.Fatalf("err = %v, want 404", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+}
+
+// tagRecorder 记录每条日志的级别和 tag
+type tagRecorder struct {
+	nopLogger
+	mu   sync.Mutex
+	tags []string
+}
+
+func (r *tagRecorder) add(level, tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tags = append(r.tags, level+" "+tag)
+}
+
+func (r *tagRecorder) Info(_ context.Context, tag string, _ any, _ ...any) { r.add("info", tag) }
+func (r *tagRecorder) Warn(_ context.Context, tag string, _ any, _ ...any) { r.add("warn", tag) }
+
+func TestReportTags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	rec := &tagRecorder{}
+	c, err := New(WithLogger(rec), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(context.Background())
+
+	for _, path := range []string{"/ok", "/fail"} {
+		var out map[string]any
+		_, _ = c.Do(context.Background(), &Request{Method: http.MethodGet, Path: path}, &out)
+	}
+	want := []string{"info " + logx.TagHttpSuccess, "warn " + logx.TagHttpFailure}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.tags) != len(want) || rec.tags[0] != want[0] || rec.tags[1] != want[1] {
+		t.Errorf("tags = %v, want %v", rec.tags, want)
+	}
+}
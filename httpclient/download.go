@@ -0,0 +1,400 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/tracex"
+)
+
+// ProgressFunc 下载进度回调：written 已写入字节数，total 总字节数（未知为 -1）
+type ProgressFunc func(written, total int64)
+
+type downloadConfig struct {
+	maxAttempts int
+	progress    ProgressFunc
+	newHash     func() hash.Hash
+	checksum    string
+}
+
+type DownloadOption func(*downloadConfig)
+
+// WithProgress 设置进度回调，每次写入后触发
+func WithProgress(fn ProgressFunc) DownloadOption {
+	return func(c *downloadConfig) { c.progress = fn }
+}
+
+// WithSHA256 下载完成后校验 SHA-256（hex）
+func WithSHA256(sum string) DownloadOption {
+	return func(c *downloadConfig) {
+		c.newHash = sha256.New
+		c.checksum = strings.ToLower(sum)
+	}
+}
+
+// WithMD5 下载完成后校验 MD5（hex）
+func WithMD5(sum string) DownloadOption {
+	return func(c *downloadConfig) {
+		c.newHash = md5.New
+		c.checksum = strings.ToLower(sum)
+	}
+}
+
+// WithDownloadAttempts 最多尝试次数（首次 + 续传），默认 max(RetryMaxAttempts, 3)
+func WithDownloadAttempts(n int) DownloadOption {
+	return func(c *downloadConfig) { c.maxAttempts = n }
+}
+
+// Download 把响应体下载到文件 dst：
+//   - 先写同目录下的临时文件，校验通过后 rename，保证 dst 要么完整要么不存在
+//   - 中途失败时用 Range + If-Range 断点续传，资源变化（ETag 不一致 / 服务端返回 200）则从头开始
+//   - 每次续传在 CallStats 中记为一次 attempt
+//   - reqCfg.Timeout 是单次 attempt 的超时，<=0 不限制（大文件依赖 ReadWriteTimeout 防止卡死）
+func (c *Client) Download(ctx context.Context, reqCfg *Request, dst string, opts ...DownloadOption) (*http.Response, error) {
+	cfg := downloadConfig{maxAttempts: max(c.retryMaxAttempts, 3)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.maxAttempts < 1 {
+		cfg.maxAttempts = 1
+	}
+
+	method := reqCfg.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	stats := &CallStats{
+		ctx:    ctx,
+		Method: method,
+		Query:  reqCfg.Query.Encode(),
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = withIdempotencyKey(ctx, reqCfg.Headers.Get(c.idempotencyHeaderName()))
+	if err := c.acquire(); err != nil {
+		stats.Err = err
+		c.report(stats)
+		return nil, err
+	}
+	// release 要在 report 之后：否则 Close 可能在日志写完前关闭自有 logger
+	defer c.release()
+	defer c.report(stats)
+
+	u, err := c.buildURL(reqCfg.Path, reqCfg.Query)
+	if err != nil {
+		stats.Err = err
+		return nil, err
+	}
+	stats.URL = u
+
+	// ---------- 临时文件 ----------
+	tmp, err := createPartFile(dst)
+	if err != nil {
+		stats.Err = err
+		return nil, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	w := &downloadWriter{f: tmp, total: -1, progress: cfg.progress}
+	if cfg.newHash != nil {
+		w.h = cfg.newHash()
+	}
+
+	var (
+		lastResp  *http.Response
+		lastErr   error
+		validator string // If-Range 使用的强校验值：ETag 或 Last-Modified
+		etag      string
+	)
+	begin := time.Now()
+	stats.MaxAttempts = cfg.maxAttempts
+	// ---------- 下载 / 续传主循环 ----------
+	for attempt := 0; attempt < cfg.maxAttempts; attempt++ {
+		aAttempt := CallAttempt{
+			Attempt: attempt + 1,
+		}
+		var retry bool
+		lastResp, retry, lastErr = func() (aResp *http.Response, retry bool, aErr error) {
+			ctx, _ := tracex.StartSpan(ctx, "http_download")
+			defer func() {
+				tracex.EndSpan(ctx, aErr)
+				aAttempt.ctx = ctx
+				aAttempt.Err = aErr
+			}()
+			if reqCfg.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeoutCause(ctx, reqCfg.Timeout, errAttemptTimeout)
+				defer cancel()
+			}
+
+			httpReq, err := http.NewRequestWithContext(ctx, method, u, nil)
+			if err != nil {
+				return nil, false, err
+			}
+			defer func() {
+				if aErr != nil && c.curlOnFailure {
+					aAttempt.Curl = c.curlCommand(httpReq, nil, false, reqCfg.Timeout)
+				}
+			}()
+			for k, vs := range c.sessionFor(ctx).mergeHeaders(cloneHeader(reqCfg.Headers)) {
+				for _, v := range vs {
+					httpReq.Header.Add(k, v)
+				}
+			}
+			if w.written > 0 {
+				httpReq.Header.Set("Range", fmt.Sprintf("bytes=%d-", w.written))
+				if validator != "" {
+					httpReq.Header.Set("If-Range", validator)
+				}
+			}
+			if stats.Path == "" && httpReq.URL != nil {
+				stats.Path = httpReq.URL.Path
+				stats.Host = httpReq.URL.Host
+			}
+
+			for _, h := range c.before {
+				h(ctx, httpReq)
+			}
+			attemptStart := time.Now()
+			resp, err := c.send(httpReq, &aAttempt)
+			for _, h := range c.after {
+				h(ctx, httpReq, resp, err)
+			}
+			defer func() {
+				aAttempt.Cost = time.Since(attemptStart)
+			}()
+			if err != nil {
+				return nil, c.retryPolicy(httpReq, nil, err), err
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			aAttempt.Status = resp.StatusCode
+			stats.Status = resp.StatusCode
+
+			switch resp.StatusCode {
+			case http.StatusOK:
+				// 首次请求，或者服务端不支持 Range / If-Range 不匹配：从头开始
+				if w.written > 0 {
+					if err := w.reset(); err != nil {
+						return resp, false, err
+					}
+				}
+				w.total = resp.ContentLength
+				etag = resp.Header.Get("ETag")
+				validator = rangeValidator(resp.Header)
+			case http.StatusPartialContent:
+				start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+				if !ok || start != w.written {
+					return resp, false, fmt.Errorf("unexpected Content-Range %q at offset %d",
+						resp.Header.Get("Content-Range"), w.written)
+				}
+				if v := resp.Header.Get("ETag"); etag != "" && v != "" && v != etag {
+					// 服务端忽略了 If-Range，但资源已经变了，下一次从头开始
+					if err := w.reset(); err != nil {
+						return resp, false, err
+					}
+					err := fmt.Errorf("resource changed: etag %s -> %s", etag, v)
+					etag, validator = "", ""
+					return resp, true, err
+				}
+				w.total = size
+			case http.StatusRequestedRangeNotSatisfiable:
+				// 上次其实已经写完，只是连接在 EOF 前断开
+				if w.total >= 0 && w.written == w.total {
+					return resp, false, nil
+				}
+				err := c.statusError(resp)
+				return resp, false, err
+			default:
+				err := c.statusError(resp)
+				return resp, c.retryPolicy(httpReq, resp, err), err
+			}
+
+			if _, err := io.Copy(w, resp.Body); err != nil {
+				// 本地写失败（磁盘满、IO 错误）重试也没用，也不算下游的错
+				var we *writeError
+				if errors.As(err, &we) {
+					return resp, false, errorx.New(errorx.ErrDownloadWrite,
+						errorx.WithMessage(fmt.Sprintf("write %s: %v", w.f.Name(), we.err)),
+						errorx.WithCause(we.err))
+				}
+				// 流中断：已写入部分保留，下一次续传
+				return resp, true, err
+			}
+			return resp, false, nil
+		}()
+
+		// 调用方取消时不再续传
+		aAttempt.WillRetry = retry && attempt < cfg.maxAttempts-1 && ctx.Err() == nil
+		stats.AttemptsLog = append(stats.AttemptsLog, aAttempt)
+		if !aAttempt.WillRetry {
+			break
+		}
+
+		// 退避等待，支持 ctx 取消
+		if sleep := c.backoff(attempt); sleep > 0 {
+			select {
+			case <-time.After(sleep):
+			case <-ctx.Done():
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			lastErr = err
+			break
+		}
+	}
+
+	stats.Cost = time.Since(begin)
+	stats.Attempts = len(stats.AttemptsLog)
+	stats.Response = map[string]any{"file": dst, "size": w.written}
+
+	if lastErr == nil {
+		lastErr = c.commitDownload(w, cfg, dst)
+		committed = lastErr == nil
+	}
+	if lastErr != nil {
+		lastErr = c.callError(stats, lastErr)
+	}
+	stats.Err = lastErr
+	return lastResp, lastErr
+}
+
+// commitDownload 校验长度与 checksum，fsync 后 rename 到 dst
+func (c *Client) commitDownload(w *downloadWriter, cfg downloadConfig, dst string) error {
+	if w.total >= 0 && w.written != w.total {
+		return errorx.New(errorx.ErrDownloadIncomplete,
+			errorx.WithMessage(fmt.Sprintf("written %d of %d bytes", w.written, w.total)))
+	}
+	if w.h != nil && cfg.checksum != "" {
+		if got := hex.EncodeToString(w.h.Sum(nil)); got != cfg.checksum {
+			return errorx.New(errorx.ErrChecksumMismatch,
+				errorx.WithMessage(fmt.Sprintf("checksum mismatch: want %s got %s", cfg.checksum, got)))
+		}
+	}
+	if err := w.f.Sync(); err != nil {
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(w.f.Name(), dst)
+}
+
+// createPartFile 在 dst 同目录下创建临时文件；不用 os.CreateTemp，
+// 它固定 0600，rename 后 dst 会变成仅属主可读，这里和 os.Create 一样按 0666 减去 umask
+func createPartFile(dst string) (*os.File, error) {
+	for range 10000 {
+		name := dst + "." + strconv.FormatUint(rand.Uint64(), 36) + ".part"
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+	return nil, &os.PathError{Op: "createtemp", Path: dst + ".*.part", Err: os.ErrExist}
+}
+
+// downloadWriter 写临时文件，同时累计 hash 和进度
+type downloadWriter struct {
+	f        *os.File
+	h        hash.Hash
+	written  int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	if n > 0 {
+		if w.h != nil {
+			_, _ = w.h.Write(p[:n])
+		}
+		w.written += int64(n)
+		if w.progress != nil {
+			w.progress(w.written, w.total)
+		}
+	}
+	if err != nil {
+		return n, &writeError{err}
+	}
+	return n, nil
+}
+
+// writeError 标记写本地文件的错误，和读响应体的错误区分开
+type writeError struct{ err error }
+
+func (e *writeError) Error() string { return e.err.Error() }
+func (e *writeError) Unwrap() error { return e.err }
+
+// reset 清空已下载内容，从头开始
+func (w *downloadWriter) reset() error {
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if w.h != nil {
+		w.h.Reset()
+	}
+	w.written = 0
+	w.total = -1
+	return nil
+}
+
+// rangeValidator If-Range 只能用强校验值：强 ETag 优先，否则用 Last-Modified
+func rangeValidator(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// parseContentRange 解析 "bytes start-end/size"，size 未知（*）时返回 -1
+func parseContentRange(s string) (start, size int64, ok bool) {
+	s, found := strings.CutPrefix(s, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, total, found := strings.Cut(s, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if total == "*" {
+		return start, -1, true
+	}
+	size, err = strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, size, true
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+// 写临时文件失败（这里把它的 fd 换成 /dev/full 模拟磁盘满）不续传，错误码区别于请求失败
+func TestDownloadWriteErrorNotRetried(t *testing.T) {
+	full, err := os.OpenFile("/dev/full", os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer full.Close()
+
+	payload := strings.Repeat("x", 1<<20)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		_, _ = w.Write([]byte(payload))
+	}))
+	defer srv.Close()
+	cli, err := New(withNopLogger(), WithBaseURL(srv.URL), WithRetry(3, nil, func(int) time.Duration { return 0 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	swapped := false
+	_, err = cli.Download(context.Background(), &Request{Path: "/"}, filepath.Join(dir, "out.bin"),
+		WithProgress(func(int64, int64) {
+			if swapped {
+				return
+			}
+			swapped = true
+			if err := dupPartFile(dir, full); err != nil {
+				t.Error(err)
+			}
+		}))
+	if !hasCode(err, errorx.ErrDownloadWrite) || !errors.Is(err, syscall.ENOSPC) {
+		t.Fatalf("want ErrDownloadWrite wrapping ENOSPC, got %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("server calls = %d, want 1", n)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("files left behind: %v", entries)
+	}
+}
+
+// dupPartFile 让 dir 下打开着的 .part 文件的 fd 指向 f
+func dupPartFile(dir string, f *os.File) error {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return err
+	}
+	for _, e := range fds {
+		target, err := os.Readlink(filepath.Join("/proc/self/fd", e.Name()))
+		if err != nil || filepath.Dir(target) != dir || !strings.HasSuffix(target, ".part") {
+			continue
+		}
+		fd, err := strconv.Atoi(e.Name())
+		if err != nil {
+			return err
+		}
+		return syscall.Dup3(int(f.Fd()), fd, 0)
+	}
+	return errors.New("part file not open")
+}
@@ -0,0 +1,131 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(context.Context, string, any, ...any) {}
+func (nopLogger) Info(context.Context, string, any, ...any)  {}
+func (nopLogger) Warn(context.Context, string, any, ...any)  {}
+func (nopLogger) Error(context.Context, string, any, ...any) {}
+func (nopLogger) Flush(context.Context) error                { return nil }
+func (nopLogger) Close(context.Context) error                { return nil }
+
+func withNopLogger() Option {
+	return func(c *Config) { c.logger = nopLogger{} }
+}
+
+func TestDownloadResume(t *testing.T) {
+	payload := strings.Repeat("orbit-download-", 1000)
+	sum := sha256.Sum256([]byte(payload))
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		start := 0
+		if rng := r.Header.Get("Range"); rng != "" {
+			if r.Header.Get("If-Range") != `"v1"` {
+				t.Errorf("If-Range = %q", r.Header.Get("If-Range"))
+			}
+			start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(payload)-1, len(payload)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)-start))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		}
+		if n == 1 {
+			// 第一次只写一半就断开
+			_, _ = w.Write([]byte(payload[:len(payload)/2]))
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		_, _ = w.Write([]byte(payload[start:]))
+	}))
+	defer srv.Close()
+
+	var stats *CallStats
+	cli, err := New(withNopLogger(), WithBaseURL(srv.URL), WithStatsHook(func(_ context.Context, s *CallStats) {
+		stats = s
+	}), WithRetry(1, nil, func(int) time.Duration { return 0 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "artifact.bin")
+	var lastWritten int64
+	_, err = cli.Download(context.Background(), &Request{Path: "/artifact"}, dst,
+		WithSHA256(hex.EncodeToString(sum[:])),
+		WithProgress(func(written, _ int64) { lastWritten = written }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != payload {
+		t.Fatalf("content mismatch: got %d bytes want %d", len(got), len(payload))
+	}
+	if lastWritten != int64(len(payload)) {
+		t.Fatalf("progress = %d, want %d", lastWritten, len(payload))
+	}
+	if stats == nil || stats.Attempts != 2 {
+		t.Fatalf("stats attempts = %+v, want 2", stats)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dst)); len(entries) != 1 {
+		t.Fatalf("temp file left behind: %v", entries)
+	}
+}
+
+// 下载得到的文件权限和 os.Create 一致（0666 减去 umask），而不是临时文件的 0600
+func TestDownloadFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions only")
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("payload"))
+	}))
+	defer srv.Close()
+	cli, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	ref, err := os.Create(filepath.Join(dir, "ref"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = ref.Close()
+	dst := filepath.Join(dir, "artifact.bin")
+	if _, err := cli.Download(context.Background(), &Request{Path: "/artifact"}, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := os.Stat(ref.Name())
+	got, err := os.Stat(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Mode().Perm() != want.Mode().Perm() {
+		t.Fatalf("mode = %v, want %v", got.Mode().Perm(), want.Mode().Perm())
+	}
+}
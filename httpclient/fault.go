@@ -0,0 +1,304 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/imattdu/orbit/cctx"
+	"github.com/imattdu/orbit/errorx"
+)
+
+// HeaderFaultInjected 注入的假响应会带上这个头，值为规则名
+const HeaderFaultInjected = "X-Fault-Injected"
+
+// 连接错误类型
+const (
+	FaultConnRefused = "refused"
+	FaultConnReset   = "reset"
+	FaultConnTimeout = "timeout"
+)
+
+// FaultRule 一条故障注入规则，匹配条件全部满足才生效（空条件表示不限制）
+type FaultRule struct {
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled,omitempty"`
+
+	// 匹配条件
+	Host     string            `json:"host,omitempty"`      // 精确匹配（含端口时连端口一起比较）
+	Path     string            `json:"path,omitempty"`      // 前缀匹配
+	Method   string            `json:"method,omitempty"`    // 不区分大小写
+	Headers  map[string]string `json:"headers,omitempty"`   // 请求头，值为空表示只要求存在
+	CtxKey   string            `json:"ctx_key,omitempty"`   // cctx 中的 key
+	CtxValue string            `json:"ctx_value,omitempty"` // 为空表示只要求 key 存在
+
+	// 触发概率 0~1，未配置（0）时不触发，>=1 总是触发；Always 为 true 时忽略概率总是触发
+	Probability float64 `json:"probability,omitempty"`
+	Always      bool    `json:"always,omitempty"`
+
+	// 故障（可组合：先加延迟，再按 ConnError > Status > TruncateBody 的顺序生效）
+	LatencyMs    int64  `json:"latency_ms,omitempty"`    // 额外延迟
+	ConnError    string `json:"conn_error,omitempty"`    // refused / reset / timeout
+	Status       int    `json:"status,omitempty"`        // 直接返回该状态码，不发真实请求
+	Body         string `json:"body,omitempty"`          // Status 生效时的响应体
+	TruncateBody int    `json:"truncate_body,omitempty"` // 真实响应体只返回前 N 字节后断开
+}
+
+// FaultInjector 故障注入器，规则可以从文件加载、运行时替换和开关，并发安全：
+// 请求只读 rules 指向的快照，快照发布后不再修改；所有写入在 mu 下复制一份改完再整体替换
+type FaultInjector struct {
+	rules   atomic.Pointer[[]FaultRule]
+	enabled atomic.Bool
+
+	mu   sync.Mutex // 保护 path，并串行化规则写入，避免并发开关时丢更新
+	path string
+}
+
+// NewFaultInjector 用给定规则创建注入器，默认开启
+func NewFaultInjector(rules ...FaultRule) *FaultInjector {
+	f := &FaultInjector{}
+	f.SetRules(rules)
+	f.enabled.Store(true)
+	return f
+}
+
+// LoadFaultInjector 从 JSON 文件加载规则（文件内容为 FaultRule 数组）
+func LoadFaultInjector(path string) (*FaultInjector, error) {
+	f := NewFaultInjector()
+	if err := f.LoadFile(path); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// LoadFile 从 JSON 文件加载并替换全部规则，记住路径供 Reload 使用
+func (f *FaultInjector) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var rules []FaultRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("parse fault rules %s: %w", path, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.path = path
+	f.storeRules(rules)
+	return nil
+}
+
+// Reload 重新读取上次 LoadFile 的文件
+func (f *FaultInjector) Reload() error {
+	f.mu.Lock()
+	path := f.path
+	f.mu.Unlock()
+	if path == "" {
+		return fmt.Errorf("fault injector: no rule file loaded")
+	}
+	return f.LoadFile(path)
+}
+
+// SetRules 整体替换规则
+func (f *FaultInjector) SetRules(rules []FaultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.storeRules(rules)
+}
+
+// storeRules 发布 rules 的副本，调用方持有 mu
+func (f *FaultInjector) storeRules(rules []FaultRule) {
+	cp := append([]FaultRule(nil), rules...)
+	f.rules.Store(&cp)
+}
+
+// Rules 返回当前规则副本
+func (f *FaultInjector) Rules() []FaultRule {
+	return append([]FaultRule(nil), f.snapshot()...)
+}
+
+// snapshot 当前规则快照，零值 FaultInjector 还没有发布过规则时为 nil
+func (f *FaultInjector) snapshot() []FaultRule {
+	if p := f.rules.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// SetEnabled 总开关
+func (f *FaultInjector) SetEnabled(on bool) {
+	f.enabled.Store(on)
+}
+
+// Enabled 是否开启
+func (f *FaultInjector) Enabled() bool {
+	return f.enabled.Load()
+}
+
+// SetRuleEnabled 按名字开关单条规则，返回是否找到
+func (f *FaultInjector) SetRuleEnabled(name string, on bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rules := f.Rules()
+	found := false
+	for i := range rules {
+		if rules[i].Name == name {
+			rules[i].Disabled = !on
+			found = true
+		}
+	}
+	if found {
+		f.rules.Store(&rules)
+	}
+	return found
+}
+
+// match 返回第一条命中的规则
+func (f *FaultInjector) match(req *http.Request) (FaultRule, bool) {
+	if f == nil || !f.enabled.Load() {
+		return FaultRule{}, false
+	}
+	for _, r := range f.snapshot() {
+		if r.Disabled || !r.matches(req) {
+			continue
+		}
+		if !r.fires() {
+			continue
+		}
+		return r, true
+	}
+	return FaultRule{}, false
+}
+
+// fires 按概率决定是否触发，漏配概率的规则默认不生效
+func (r *FaultRule) fires() bool {
+	switch {
+	case r.Always || r.Probability >= 1:
+		return true
+	case r.Probability <= 0:
+		return false
+	default:
+		return rand.Float64() < r.Probability
+	}
+}
+
+func (r *FaultRule) matches(req *http.Request) bool {
+	if r.Host != "" && !strings.EqualFold(r.Host, req.URL.Host) && !strings.EqualFold(r.Host, req.URL.Hostname()) {
+		return false
+	}
+	if r.Path != "" && !strings.HasPrefix(req.URL.Path, r.Path) {
+		return false
+	}
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+	for k, v := range r.Headers {
+		got := req.Header.Values(k)
+		if len(got) == 0 || (v != "" && got[0] != v) {
+			return false
+		}
+	}
+	if r.CtxKey != "" {
+		v, ok := cctx.Get(req.Context(), r.CtxKey)
+		if !ok || (r.CtxValue != "" && fmt.Sprint(v) != r.CtxValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithFaultInjector 开启故障注入，仅用于测试 / 演练环境
+func WithFaultInjector(f *FaultInjector) Option {
+	return func(c *Config) { c.FaultInjector = f }
+}
+
+// inject 按命中的规则执行故障：handled=true 时 resp/err 即本次结果，不再发真实请求
+func (r *FaultRule) inject(ctx context.Context, req *http.Request) (resp *http.Response, handled bool, err error) {
+	if r.LatencyMs > 0 {
+		select {
+		case <-time.After(time.Duration(r.LatencyMs) * time.Millisecond):
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+
+	switch {
+	case r.ConnError != "":
+		return nil, true, r.connError(req)
+	case r.Status > 0:
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+			StatusCode:    r.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{HeaderFaultInjected: []string{r.Name}},
+			Body:          io.NopCloser(strings.NewReader(r.Body)),
+			ContentLength: int64(len(r.Body)),
+			Request:       req,
+		}, true, nil
+	}
+	return nil, false, nil
+}
+
+func (r *FaultRule) connError(req *http.Request) error {
+	var cause error
+	switch r.ConnError {
+	case FaultConnReset:
+		cause = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	case FaultConnTimeout:
+		cause = &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	default:
+		cause = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+	return errorx.New(errorx.ErrFaultInjected,
+		errorx.WithMessage(fmt.Sprintf("fault %q injected %s on %s", r.Name, r.ConnError, req.URL.Host)),
+		errorx.WithCause(cause),
+		errorx.WithField("fault", r.Name),
+	)
+}
+
+// faultDesc 记录在 CallAttempt.Fault 上的描述
+func (r *FaultRule) faultDesc() string {
+	parts := make([]string, 0, 4)
+	if r.LatencyMs > 0 {
+		parts = append(parts, fmt.Sprintf("latency=%dms", r.LatencyMs))
+	}
+	switch {
+	case r.ConnError != "":
+		parts = append(parts, "conn_error="+r.ConnError)
+	case r.Status > 0:
+		parts = append(parts, fmt.Sprintf("status=%d", r.Status))
+	case r.TruncateBody > 0:
+		parts = append(parts, fmt.Sprintf("truncate=%d", r.TruncateBody))
+	}
+	return r.Name + ":" + strings.Join(parts, ",")
+}
+
+// truncatedBody 只返回前 n 字节，然后模拟连接中断
+type truncatedBody struct {
+	io.ReadCloser
+	remain int
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remain <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if len(p) > b.remain {
+		p = p[:b.remain]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remain -= n
+	return n, err
+}
@@ -0,0 +1,217 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/imattdu/orbit/cctx"
+	"github.com/imattdu/orbit/errorx"
+)
+
+func TestFaultRuleMatch(t *testing.T) {
+	ctx := cctx.With(context.Background(), "tenant", "gray")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://user:8080/api/v1/users?id=1", nil)
+	req.Header.Set("X-Env", "staging")
+
+	cases := []struct {
+		name string
+		rule FaultRule
+		want bool
+	}{
+		{"empty", FaultRule{}, true},
+		{"host with port", FaultRule{Host: "user:8080"}, true},
+		{"hostname", FaultRule{Host: "USER"}, true},
+		{"other host", FaultRule{Host: "order"}, false},
+		{"path prefix", FaultRule{Path: "/api/v1"}, true},
+		{"other path", FaultRule{Path: "/api/v2"}, false},
+		{"method", FaultRule{Method: "post"}, true},
+		{"other method", FaultRule{Method: http.MethodGet}, false},
+		{"header value", FaultRule{Headers: map[string]string{"x-env": "staging"}}, true},
+		{"header exists", FaultRule{Headers: map[string]string{"X-Env": ""}}, true},
+		{"header mismatch", FaultRule{Headers: map[string]string{"X-Env": "prod"}}, false},
+		{"header missing", FaultRule{Headers: map[string]string{"X-Canary": ""}}, false},
+		{"ctx value", FaultRule{CtxKey: "tenant", CtxValue: "gray"}, true},
+		{"ctx exists", FaultRule{CtxKey: "tenant"}, true},
+		{"ctx mismatch", FaultRule{CtxKey: "tenant", CtxValue: "blue"}, false},
+		{"ctx missing", FaultRule{CtxKey: "user"}, false},
+		{"all", FaultRule{Host: "user", Path: "/api", Method: "POST", CtxKey: "tenant"}, true},
+	}
+	for _, tc := range cases {
+		if got := tc.rule.matches(req); got != tc.want {
+			t.Errorf("%s: matches = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFaultProbability(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://user/", nil)
+	count := func(r FaultRule) int {
+		n := 0
+		f := NewFaultInjector(r)
+		for range 1000 {
+			if _, ok := f.match(req); ok {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(FaultRule{Name: "unset"}); n != 0 {
+		t.Fatalf("rule without probability fired %d times", n)
+	}
+	if n := count(FaultRule{Name: "always", Always: true}); n != 1000 {
+		t.Fatalf("always rule fired %d times", n)
+	}
+	if n := count(FaultRule{Name: "one", Probability: 1}); n != 1000 {
+		t.Fatalf("probability 1 fired %d times", n)
+	}
+	if n := count(FaultRule{Name: "half", Probability: 0.5}); n < 350 || n > 650 {
+		t.Fatalf("probability 0.5 fired %d times", n)
+	}
+
+	f := NewFaultInjector(FaultRule{Name: "r", Always: true})
+	f.SetEnabled(false)
+	if _, ok := f.match(req); ok {
+		t.Fatal("disabled injector matched")
+	}
+	f.SetEnabled(true)
+	f.SetRuleEnabled("r", false)
+	if _, ok := f.match(req); ok {
+		t.Fatal("disabled rule matched")
+	}
+}
+
+// 请求进行中开关规则：go test -race 下不能有数据竞争，并发开关不同规则也不能丢更新
+func TestFaultToggleWhileRunning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	f := NewFaultInjector(
+		FaultRule{Name: "a", Always: true, Status: http.StatusServiceUnavailable},
+		FaultRule{Name: "b", Always: true, LatencyMs: 1},
+	)
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL), WithFaultInjector(f), WithRetry(0, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_, _ = c.GetJSON(context.Background(), "/", nil)
+			}
+		}()
+	}
+	var toggles sync.WaitGroup
+	for _, name := range []string{"a", "b"} {
+		toggles.Add(1)
+		go func() {
+			defer toggles.Done()
+			for i := range 200 {
+				f.SetRuleEnabled(name, i%2 == 0)
+			}
+			// 最后一次：a 关闭，b 打开
+			f.SetRuleEnabled(name, name == "b")
+		}()
+	}
+	toggles.Wait()
+	close(stop)
+	wg.Wait()
+
+	for _, r := range f.Rules() {
+		if r.Disabled != (r.Name == "a") {
+			t.Fatalf("rule %s disabled=%v, update lost", r.Name, r.Disabled)
+		}
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{"name":"` + strings.Repeat("x", 100) + `"}`))
+	}))
+	defer srv.Close()
+
+	f := NewFaultInjector()
+	var stats *CallStats
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL), WithFaultInjector(f),
+		WithStatsHook(func(_ context.Context, s *CallStats) { stats = s }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	var out map[string]any
+
+	// 延迟：仍然发真实请求
+	f.SetRules([]FaultRule{{Name: "slow", Always: true, LatencyMs: 50}})
+	start := time.Now()
+	if _, err := c.GetJSON(ctx, "/", &out); err != nil || time.Since(start) < 50*time.Millisecond || hits != 1 {
+		t.Fatalf("latency: err=%v cost=%s hits=%d", err, time.Since(start), hits)
+	}
+	if got := stats.AttemptsLog[0].Fault; got != "slow:latency=50ms" {
+		t.Fatalf("attempt fault = %q", got)
+	}
+
+	// 连接错误：不发真实请求
+	f.SetRules([]FaultRule{{Name: "reset", Always: true, ConnError: FaultConnReset}})
+	_, err = c.GetJSON(ctx, "/", &out)
+	if !hasCode(err, errorx.ErrFaultInjected) || !errors.Is(err, syscall.ECONNRESET) || hits != 1 {
+		t.Fatalf("conn error: %v hits=%d", err, hits)
+	}
+
+	// 状态码
+	f.SetRules([]FaultRule{{Name: "unavailable", Always: true, Status: http.StatusServiceUnavailable, Body: "down"}})
+	resp, err := c.GetJSON(ctx, "/", &out)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable ||
+		resp.Header.Get(HeaderFaultInjected) != "unavailable" || hits != 1 {
+		t.Fatalf("status: resp=%v err=%v hits=%d", resp, err, hits)
+	}
+
+	// 截断响应体
+	f.SetRules([]FaultRule{{Name: "cut", Always: true, TruncateBody: 10}})
+	var raw []byte
+	if _, err := c.Do(ctx, &Request{Method: http.MethodGet, Path: "/"}, &raw); err == nil {
+		t.Fatalf("truncate: read %q without error", raw)
+	}
+	if hits != 2 {
+		t.Fatalf("truncate should reach the server, hits=%d", hits)
+	}
+}
+
+// 零值 FaultInjector 可以直接使用
+func TestFaultInjectorZeroValue(t *testing.T) {
+	var f FaultInjector
+	if rules := f.Rules(); len(rules) != 0 {
+		t.Fatalf("rules = %v", rules)
+	}
+	f.SetEnabled(true)
+	req, _ := http.NewRequest(http.MethodGet, "http://user/", nil)
+	if _, ok := f.match(req); ok {
+		t.Fatal("zero-value injector matched")
+	}
+	if f.SetRuleEnabled("x", false) {
+		t.Fatal("found a rule in an empty injector")
+	}
+	f.SetRules([]FaultRule{{Name: "x", Always: true}})
+	if _, ok := f.match(req); !ok {
+		t.Fatal("rule not matched after SetRules")
+	}
+}
@@ -0,0 +1,142 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+const defaultGraphQLPath = "/graphql"
+
+// GraphQLRequest 一次 GraphQL 调用
+type GraphQLRequest struct {
+	Path          string // 默认 /graphql，也可以是完整 URL
+	Query         string
+	OperationName string
+	Variables     map[string]any
+
+	// PersistedQuery 开启 APQ：先只发送 query 的 sha256，服务端返回 PersistedQueryNotFound 时再带上完整 query
+	PersistedQuery bool
+
+	Headers http.Header
+	Timeout time.Duration
+}
+
+// GraphQLError GraphQL 响应 errors 数组中的一项
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Locations  []GraphQLLoc   `json:"locations,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+type GraphQLLoc struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type graphqlBody struct {
+	Query         string         `json:"query,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// GraphQL 发送 GraphQL 请求，把 data 解码到 data（可为 nil）；
+//   - 响应带 errors 时返回 errorx.ErrGraphQL，Fields 里有 operation / path / extensions / errors，
+//     部分成功的 data 仍会被解码
+//   - 日志 path 与 span 名为 "graphql:<OperationName>"
+func (c *Client) GraphQL(ctx context.Context, req *GraphQLRequest, data any) (*http.Response, error) {
+	path := req.Path
+	if path == "" {
+		path = defaultGraphQLPath
+	}
+	op := "graphql"
+	if req.OperationName != "" {
+		op += ":" + req.OperationName
+	}
+
+	body := graphqlBody{OperationName: req.OperationName, Variables: req.Variables, Query: req.Query}
+	if req.PersistedQuery {
+		sum := sha256.Sum256([]byte(req.Query))
+		body.Query = ""
+		body.Extensions = map[string]any{
+			"persistedQuery": map[string]any{"version": 1, "sha256Hash": hex.EncodeToString(sum[:])},
+		}
+	}
+
+	call := func() (*http.Response, *graphqlResponse, error) {
+		var out graphqlResponse
+		resp, err := c.Do(ctx, &Request{
+			Method:    http.MethodPost,
+			Path:      path,
+			Headers:   req.Headers,
+			Body:      body,
+			Timeout:   req.Timeout,
+			Operation: op,
+			Decode:    &DecodeOptions{AllowEmpty: true},
+		}, &out)
+		return resp, &out, err
+	}
+
+	resp, out, err := call()
+	if req.PersistedQuery && persistedQueryNotFound(out.Errors) {
+		// 服务端还没有缓存该 query，带上完整 query 注册
+		body.Query = req.Query
+		resp, out, err = call()
+	}
+
+	if data != nil && len(out.Data) > 0 && string(out.Data) != "null" {
+		if e := json.Unmarshal(out.Data, data); e != nil && err == nil {
+			err = e
+		}
+	}
+	if len(out.Errors) > 0 {
+		return resp, c.graphqlError(op, out.Errors, err)
+	}
+	return resp, err
+}
+
+// graphqlError 把 errors 数组转成 errorx.Error，首个错误的信息放在 message / path / extensions
+func (c *Client) graphqlError(op string, errs []GraphQLError, cause error) error {
+	first := errs[0]
+	msg := first.Message
+	if len(errs) > 1 {
+		msg = fmt.Sprintf("%s (and %d more errors)", msg, len(errs)-1)
+	}
+	opts := append([]errorx.Option{
+		errorx.WithMessage(msg),
+		errorx.WithField("operation", op),
+		errorx.WithField("errors", errs),
+	}, c.serviceOpts()...)
+	if len(first.Path) > 0 {
+		opts = append(opts, errorx.WithField("path", first.Path))
+	}
+	if len(first.Extensions) > 0 {
+		opts = append(opts, errorx.WithField("extensions", first.Extensions))
+	}
+	if cause != nil {
+		opts = append(opts, errorx.WithCause(cause))
+	}
+	return errorx.New(errorx.ErrGraphQL, opts...)
+}
+
+func persistedQueryNotFound(errs []GraphQLError) bool {
+	for _, e := range errs {
+		if e.Message == "PersistedQueryNotFound" || e.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+func TestGraphQLPersistedQueryAndErrors(t *testing.T) {
+	var registered bool
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var body graphqlBody
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case body.Query == "" && !registered:
+			_, _ = w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+		default:
+			registered = true
+			_, _ = w.Write([]byte(`{"data":{"user":{"name":"` + body.Variables["id"].(string) + `"},"friends":null},
+				"errors":[{"message":"forbidden","path":["friends"],"extensions":{"code":"FORBIDDEN"}}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		User struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+	_, err = c.GraphQL(context.Background(), &GraphQLRequest{
+		Query:          `query GetUser($id: ID!) { user(id: $id) { name } friends { name } }`,
+		OperationName:  "GetUser",
+		Variables:      map[string]any{"id": "u1"},
+		PersistedQuery: true,
+	}, &data)
+
+	if calls != 2 || data.User.Name != "u1" {
+		t.Fatalf("calls=%d data=%+v", calls, data)
+	}
+	e, ok := errorx.From(err)
+	if !ok || e.Code.Code != errorx.ErrGraphQL.Code {
+		t.Fatalf("want ErrGraphQL, got %v", err)
+	}
+	if e.Fields["operation"] != "graphql:GetUser" || e.Fields["extensions"].(map[string]any)["code"] != "FORBIDDEN" {
+		t.Fatalf("fields: %+v", e.Fields)
+	}
+}
@@ -0,0 +1,102 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HeaderIdempotencyKey 常用的幂等键请求头
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// WithIdempotencyHeader 开启幂等键：非幂等方法（POST / PATCH）每次逻辑调用生成一个 key，
+// 所有重试都带上同一个 key，header 为空时使用 HeaderIdempotencyKey
+func WithIdempotencyHeader(header string) Option {
+	return func(c *Config) {
+		if header == "" {
+			header = HeaderIdempotencyKey
+		}
+		c.IdempotencyHeader = header
+	}
+}
+
+// WithIdempotencyKey 调用方显式指定幂等键（例如业务单号），优先级高于自动生成
+func WithIdempotencyKey(key string) RequestOption {
+	return func(r *Request) { r.IdempotencyKey = key }
+}
+
+// RetryIdempotent 包装 RetryDecider：幂等方法照常判断，
+// 非幂等方法只有本次调用带了幂等键（WithIdempotencyHeader 生成、WithIdempotencyKey 或请求头指定）才允许重试
+func RetryIdempotent(dec RetryDecider) RetryPolicy {
+	if dec == nil {
+		dec = defaultRetryDecider
+	}
+	return func(req *http.Request, resp *http.Response, err error) bool {
+		if !dec(resp, err) {
+			return false
+		}
+		if req == nil || isIdempotentMethod(req.Method) {
+			return true
+		}
+		return idempotencyKeyFrom(req.Context()) != ""
+	}
+}
+
+type idempotencyCtxKey struct{}
+
+// withIdempotencyKey 记录本次调用的幂等键，key 为空也要写入，避免沿用外层调用的 key
+func withIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyCtxKey{}, key)
+}
+
+func idempotencyKeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyCtxKey{}).(string)
+	return key
+}
+
+// isIdempotentMethod 按 RFC 9110 判断方法是否幂等
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotencyKey 决定本次逻辑调用使用的幂等 header 和 key，key 为空表示不需要；
+// 调用方已经带了 key 时沿用，未开启 WithIdempotencyHeader 时不自动生成
+func (c *Client) idempotencyKey(reqCfg *Request, headers http.Header) (string, string) {
+	header := c.idempotencyHeaderName()
+	if v := headers.Get(header); v != "" {
+		return header, v
+	}
+	if reqCfg.IdempotencyKey != "" {
+		return header, reqCfg.IdempotencyKey
+	}
+	if c.idempotencyHeader == "" || isIdempotentMethod(reqCfg.Method) {
+		return header, ""
+	}
+	return header, newIdempotencyKey()
+}
+
+// idempotencyHeaderName 幂等键使用的请求头，未开启时为 HeaderIdempotencyKey
+func (c *Client) idempotencyHeaderName() string {
+	if c.idempotencyHeader != "" {
+		return c.idempotencyHeader
+	}
+	return HeaderIdempotencyKey
+}
+
+// newIdempotencyKey 生成 128 bit 的随机 key（32 位 hex）
+func newIdempotencyKey() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b[:])
+}
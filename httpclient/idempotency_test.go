@@ -0,0 +1,105 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keyRecorder 记录每次请求的幂等键，前 fails 次返回 503
+type keyRecorder struct {
+	header string
+	fails  int
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (k *keyRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	k.mu.Lock()
+	k.keys = append(k.keys, r.Header.Get(k.header))
+	n := len(k.keys)
+	k.mu.Unlock()
+	if n <= k.fails {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func newIdempotentClient(t *testing.T, srv *httptest.Server, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{withNopLogger(), WithBaseURL(srv.URL),
+		WithRetry(3, nil, func(int) time.Duration { return 0 }),
+		WithRetryPolicy(RetryIdempotent(nil)),
+	}, opts...)
+	c, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = c.Close(context.Background()) })
+	return c
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		name     string
+		header   string // WithIdempotencyHeader，空表示不开启
+		method   string
+		opts     []RequestOption
+		attempts int
+		want     string // 期望的 key，"*" 表示自动生成
+	}{
+		{"generated key stable across retries", "X-Request-Key", http.MethodPost, nil, 3, "*"},
+		{"caller key option", "X-Request-Key", http.MethodPost, []RequestOption{WithIdempotencyKey("order-1")}, 3, "order-1"},
+		{"caller key header", "X-Request-Key", http.MethodPost, []RequestOption{WithHeader("X-Request-Key", "order-2")}, 3, "order-2"},
+		{"caller key without header option", "", http.MethodPost, []RequestOption{WithIdempotencyKey("order-3")}, 3, "order-3"},
+		{"get has no key", "X-Request-Key", http.MethodGet, nil, 3, ""},
+		{"post without key is not retried", "", http.MethodPost, nil, 1, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := tc.header
+			if header == "" {
+				header = HeaderIdempotencyKey
+			}
+			rec := &keyRecorder{header: header, fails: 2}
+			srv := httptest.NewServer(rec)
+			defer srv.Close()
+			var opts []Option
+			if tc.header != "" {
+				opts = append(opts, WithIdempotencyHeader(tc.header))
+			}
+			c := newIdempotentClient(t, srv, opts...)
+
+			req := &Request{Method: tc.method, Path: "/orders"}
+			for _, o := range tc.opts {
+				o(req)
+			}
+			var out map[string]any
+			_, _ = c.Do(ctx, req, &out)
+
+			if len(rec.keys) != tc.attempts {
+				t.Fatalf("attempts = %d, want %d (keys %q)", len(rec.keys), tc.attempts, rec.keys)
+			}
+			for _, k := range rec.keys {
+				if k != rec.keys[0] {
+					t.Fatalf("key changed across retries: %q", rec.keys)
+				}
+			}
+			switch got := rec.keys[0]; tc.want {
+			case "*":
+				if len(got) != 32 {
+					t.Fatalf("generated key = %q", got)
+				}
+			default:
+				if got != tc.want {
+					t.Fatalf("key = %q, want %q", got, tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+// RPCClient JSON-RPC 2.0 over HTTP，复用 Client 的重试、统计和日志；
+// 日志 path 与 span 名为 "jsonrpc:<method>"，批量调用为 "jsonrpc:batch"
+type RPCClient struct {
+	c      *Client
+	path   string
+	nextID atomic.Uint64
+}
+
+// JSONRPC 创建指向 path（相对 BaseURL 或完整 URL）的 JSON-RPC client
+func (c *Client) JSONRPC(path string) *RPCClient {
+	return &RPCClient{c: c, path: path}
+}
+
+// RPCError JSON-RPC 错误对象，也是远端错误对应的 errorx.ErrJSONRPC 的 Cause：
+// errors.As 取出远端 code / data，errors.Is 按 Code 匹配（如 ErrRPCMethodNotFound）
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// JSON-RPC 2.0 预定义的错误，用于 errors.Is
+var (
+	ErrRPCParse          = &RPCError{Code: -32700, Message: "parse error"}
+	ErrRPCInvalidRequest = &RPCError{Code: -32600, Message: "invalid request"}
+	ErrRPCMethodNotFound = &RPCError{Code: -32601, Message: "method not found"}
+	ErrRPCInvalidParams  = &RPCError{Code: -32602, Message: "invalid params"}
+	ErrRPCInternal       = &RPCError{Code: -32603, Message: "internal error"}
+)
+
+func (e *RPCError) Error() string {
+	return "jsonrpc error " + strconv.Itoa(e.Code) + ": " + e.Message
+}
+
+// Is 按 Code 匹配
+func (e *RPCError) Is(target error) bool {
+	t, ok := target.(*RPCError)
+	return ok && t.Code == e.Code
+}
+
+// RPCCall 批量调用中的一项；Notify 为 true 时不带 id，服务端不回包
+type RPCCall struct {
+	Method string
+	Params any
+	Result any // 结果解码目标，可为 nil
+	Notify bool
+
+	Err error // 该项的错误（远端错误对象为 errorx.ErrJSONRPC，Cause 是 *RPCError）
+}
+
+type rpcRequest struct {
+	JSONRPC string  `json:"jsonrpc"`
+	ID      *uint64 `json:"id,omitempty"`
+	Method  string  `json:"method"`
+	Params  any     `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// Call 调用 method，把 result 解码到 result（可为 nil）
+func (r *RPCClient) Call(ctx context.Context, method string, params any, result any, opts ...RequestOption) error {
+	id := r.nextID.Add(1)
+	var resp rpcResponse
+	if err := r.post(ctx, "jsonrpc:"+method, rpcRequest{JSONRPC: "2.0", ID: &id, Method: method, Params: params}, &resp, opts); err != nil {
+		return err
+	}
+	return r.result(method, &resp, result)
+}
+
+// Notify 发送通知（无 id，不关心结果）
+func (r *RPCClient) Notify(ctx context.Context, method string, params any, opts ...RequestOption) error {
+	return r.post(ctx, "jsonrpc:"+method, rpcRequest{JSONRPC: "2.0", Method: method, Params: params}, nil, opts)
+}
+
+// Batch 一次 HTTP 请求发送多个调用，响应按 id 匹配回各项的 Result / Err；
+// 返回值只表示整体传输失败，单项失败看 RPCCall.Err
+func (r *RPCClient) Batch(ctx context.Context, calls []*RPCCall, opts ...RequestOption) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	reqs := make([]rpcRequest, len(calls))
+	byID := make(map[string]*RPCCall, len(calls))
+	for i, call := range calls {
+		reqs[i] = rpcRequest{JSONRPC: "2.0", Method: call.Method, Params: call.Params}
+		if !call.Notify {
+			id := r.nextID.Add(1)
+			reqs[i].ID = &id
+			byID[strconv.FormatUint(id, 10)] = call
+		}
+	}
+
+	var resps []rpcResponse
+	var out any
+	if len(byID) > 0 {
+		out = &resps
+	}
+	if err := r.post(ctx, "jsonrpc:batch", reqs, out, opts); err != nil {
+		for _, call := range calls {
+			call.Err = err
+		}
+		return err
+	}
+	for i := range resps {
+		call, ok := byID[string(bytes.TrimSpace(resps[i].ID))]
+		if !ok {
+			continue
+		}
+		delete(byID, string(bytes.TrimSpace(resps[i].ID)))
+		call.Err = r.result(call.Method, &resps[i], call.Result)
+	}
+	for id, call := range byID {
+		call.Err = r.rpcError(call.Method, &RPCError{Message: "no response for id " + id})
+	}
+	return nil
+}
+
+func (r *RPCClient) post(ctx context.Context, op string, body any, out any, opts []RequestOption) error {
+	req := &Request{Method: http.MethodPost, Path: r.path, Body: body, Operation: op}
+	for _, opt := range opts {
+		opt(req)
+	}
+	if req.Decode == nil {
+		// 通知的响应通常是 204 / 空 body
+		req.Decode = &DecodeOptions{AllowEmpty: true}
+	}
+	if out == nil {
+		out = new(json.RawMessage)
+	}
+	_, err := r.c.Do(ctx, req, out)
+	return err
+}
+
+func (r *RPCClient) result(method string, resp *rpcResponse, result any) error {
+	if resp.Error != nil {
+		return r.rpcError(method, resp.Error)
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("jsonrpc %s: decode result: %w", method, err)
+	}
+	return nil
+}
+
+// rpcError 远端错误对象 → errorx.ErrJSONRPC，Cause 为 e，Fields 里有 rpc_method / rpc_code / rpc_data
+func (r *RPCClient) rpcError(method string, e *RPCError) error {
+	opts := append([]errorx.Option{
+		errorx.WithMessage(e.Message),
+		errorx.WithCause(e),
+		errorx.WithField("rpc_method", method),
+		errorx.WithField("rpc_code", e.Code),
+	}, r.c.serviceOpts()...)
+	if len(e.Data) > 0 {
+		opts = append(opts, errorx.WithField("rpc_data", e.Data))
+	}
+	return errorx.New(errorx.ErrJSONRPC, opts...)
+}
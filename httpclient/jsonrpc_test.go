@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+func TestJSONRPCBatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&reqs)
+		var out []map[string]any
+		// 倒序回包，验证按 id 匹配
+		for i := len(reqs) - 1; i >= 0; i-- {
+			req := reqs[i]
+			if req.ID == nil {
+				continue
+			}
+			resp := map[string]any{"jsonrpc": "2.0", "id": *req.ID}
+			if req.Method == "add" {
+				p := req.Params.([]any)
+				resp["result"] = p[0].(float64) + p[1].(float64)
+			} else {
+				resp["error"] = map[string]any{"code": -32601, "message": "method not found", "data": req.Method}
+			}
+			out = append(out, resp)
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sum int
+	calls := []*RPCCall{
+		{Method: "add", Params: []int{1, 2}, Result: &sum},
+		{Method: "log", Params: []string{"hi"}, Notify: true},
+		{Method: "nope"},
+	}
+	if err := c.JSONRPC("/rpc").Batch(context.Background(), calls); err != nil {
+		t.Fatal(err)
+	}
+	if calls[0].Err != nil || sum != 3 || calls[1].Err != nil {
+		t.Fatalf("add: sum=%d err=%v notify err=%v", sum, calls[0].Err, calls[1].Err)
+	}
+	e, ok := errorx.From(calls[2].Err)
+	if !ok || e.Code.Code != errorx.ErrJSONRPC.Code || e.Fields["rpc_code"] != -32601 {
+		t.Fatalf("nope: %v", calls[2].Err)
+	}
+	// 远端 code 可以用 errors.Is / errors.As 取到
+	var rpcErr *RPCError
+	if !errors.Is(calls[2].Err, ErrRPCMethodNotFound) || errors.Is(calls[2].Err, ErrRPCInvalidParams) ||
+		!errors.As(calls[2].Err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf("nope: remote code not exposed: %v", calls[2].Err)
+	}
+}
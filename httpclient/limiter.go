@@ -0,0 +1,235 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+// LimitAlgorithm 根据一次请求的结果计算新的并发上限
+//   - limit    当前上限
+//   - rtt      本次请求耗时（到拿到响应头为止）
+//   - inflight 本次请求开始时的在途请求数（含自己）
+//   - dropped  本次请求是否视为过载信号（网络错误、client 超时、429 / 503）；调用方自己取消或 ctx 到期不算
+type LimitAlgorithm interface {
+	Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64
+}
+
+// AIMD 加性增、乘性减：没有过载信号且在途请求接近上限时 +Increase，出现过载信号时乘以 Backoff
+type AIMD struct {
+	Min      int           // 下限，默认 1
+	Max      int           // 上限，默认 1000
+	Increase float64       // 每次增加量，默认 1
+	Backoff  float64       // 过载时的衰减系数，默认 0.9
+	Timeout  time.Duration // rtt 超过该值也视为过载，<=0 不启用
+}
+
+func (a *AIMD) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+	inc, backoff := a.Increase, a.Backoff
+	if inc <= 0 {
+		inc = 1
+	}
+	if backoff <= 0 || backoff >= 1 {
+		backoff = 0.9
+	}
+	if dropped || (a.Timeout > 0 && rtt > a.Timeout) {
+		limit *= backoff
+	} else if float64(inflight)*2 >= limit {
+		// 只有真的用到了一半以上的额度才增长，避免空闲时无限上涨
+		limit += inc
+	}
+	return clampLimit(limit, a.Min, a.Max)
+}
+
+// Gradient 梯度 / Vegas 风格：比较长期平均 rtt 与本次 rtt，rtt 变大说明开始排队，按比例收缩
+type Gradient struct {
+	Min       int     // 下限，默认 1
+	Max       int     // 上限，默认 1000
+	Tolerance float64 // 允许 rtt 上涨的倍数，默认 1.5
+	Smoothing float64 // 新旧 limit 的平滑系数 (0,1]，默认 0.2
+
+	mu      sync.Mutex
+	longRTT float64 // 纳秒，指数移动平均
+}
+
+func (g *Gradient) Update(limit float64, rtt time.Duration, inflight int, dropped bool) float64 {
+	tolerance, smoothing := g.Tolerance, g.Smoothing
+	if tolerance < 1 {
+		tolerance = 1.5
+	}
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.2
+	}
+
+	g.mu.Lock()
+	short := float64(rtt)
+	if g.longRTT == 0 {
+		g.longRTT = short
+	} else {
+		g.longRTT = g.longRTT*0.95 + short*0.05
+	}
+	long := g.longRTT
+	g.mu.Unlock()
+
+	// 请求量远低于上限时，rtt 信息不足以支撑增长
+	if !dropped && float64(inflight) < limit/2 {
+		return clampLimit(limit, g.Min, g.Max)
+	}
+
+	gradient := 0.5
+	if !dropped && short > 0 {
+		gradient = math.Max(0.5, math.Min(1, tolerance*long/short))
+	}
+	queue := math.Sqrt(limit)
+	next := limit*gradient + queue
+	next = limit*(1-smoothing) + next*smoothing
+	return clampLimit(next, g.Min, g.Max)
+}
+
+func clampLimit(limit float64, lo, hi int) float64 {
+	if lo <= 0 {
+		lo = 1
+	}
+	if hi <= 0 {
+		hi = 1000
+	}
+	return math.Max(float64(lo), math.Min(float64(hi), limit))
+}
+
+// AdaptiveLimiter 单个下游的自适应并发限制器，并发安全
+type AdaptiveLimiter struct {
+	algo LimitAlgorithm
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+}
+
+// NewAdaptiveLimiter 创建限制器，initial 为初始上限
+func NewAdaptiveLimiter(algo LimitAlgorithm, initial int) *AdaptiveLimiter {
+	if algo == nil {
+		algo = &AIMD{}
+	}
+	if initial <= 0 {
+		initial = 20
+	}
+	return &AdaptiveLimiter{algo: algo, limit: float64(initial)}
+}
+
+// Acquire 尝试占用一个名额，超过上限时返回 ok=false；
+// 成功时必须调用 release 上报结果
+func (l *AdaptiveLimiter) Acquire() (release func(rtt time.Duration, dropped bool), ok bool) {
+	l.mu.Lock()
+	if l.inflight >= int(l.limit) {
+		l.mu.Unlock()
+		return nil, false
+	}
+	l.inflight++
+	inflight := l.inflight
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func(rtt time.Duration, dropped bool) {
+		once.Do(func() {
+			l.mu.Lock()
+			l.inflight--
+			l.limit = l.algo.Update(l.limit, rtt, inflight, dropped)
+			l.mu.Unlock()
+		})
+	}, true
+}
+
+// Limit 当前上限
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Inflight 当前在途请求数
+func (l *AdaptiveLimiter) Inflight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// WithAdaptiveLimit 按下游 host 开启自适应并发限制，每个 host 用 newAlgo 创建独立的算法实例；
+// 超过上限的请求直接失败，返回 errorx.ErrConcurrencyLimited
+func WithAdaptiveLimit(newAlgo func() LimitAlgorithm, initial int) Option {
+	return func(c *Config) {
+		c.AdaptiveLimit = func() *AdaptiveLimiter {
+			var algo LimitAlgorithm
+			if newAlgo != nil {
+				algo = newAlgo()
+			}
+			return NewAdaptiveLimiter(algo, initial)
+		}
+	}
+}
+
+// limiterFor 取 host 对应的限制器，未开启时返回 nil
+func (c *Client) limiterFor(host string) *AdaptiveLimiter {
+	if c.newLimiter == nil {
+		return nil
+	}
+	if l, ok := c.limiters.Load(host); ok {
+		return l.(*AdaptiveLimiter)
+	}
+	l, _ := c.limiters.LoadOrStore(host, c.newLimiter())
+	return l.(*AdaptiveLimiter)
+}
+
+// Limits 返回各下游 host 当前的并发上限，便于观察收敛情况
+func (c *Client) Limits() map[string]int {
+	out := make(map[string]int)
+	c.limiters.Range(func(k, v any) bool {
+		out[k.(string)] = v.(*AdaptiveLimiter).Limit()
+		return true
+	})
+	return out
+}
+
+// errAttemptTimeout client 自己给单次 attempt 设置的超时，作为 ctx 的 cause 与调用方的超时区分；
+// 仍然满足 errors.Is(err, context.DeadlineExceeded)
+var errAttemptTimeout error = attemptTimeoutError{}
+
+type attemptTimeoutError struct{}
+
+func (attemptTimeoutError) Error() string        { return "httpclient: attempt timeout" }
+func (attemptTimeoutError) Timeout() bool        { return true }
+func (attemptTimeoutError) Is(target error) bool { return target == context.DeadlineExceeded }
+
+// isOverload 是否把本次结果视为过载信号：
+//   - 调用方取消、或调用方 ctx 到期不算，后端可能是健康的
+//   - client 自己的 attempt 超时、其余网络错误、429 / 503 算
+func isOverload(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		if ctx.Err() != nil && !errors.Is(context.Cause(ctx), errAttemptTimeout) {
+			return false
+		}
+		return true
+	}
+	return resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable)
+}
+
+func limitedError(host string, limit int, service errorx.CodeEntry) error {
+	opts := []errorx.Option{
+		errorx.WithField("host", host),
+		errorx.WithField("limit", limit),
+	}
+	if service.Code != 0 {
+		opts = append(opts, errorx.WithService(service))
+	}
+	return errorx.New(errorx.ErrConcurrencyLimited, opts...)
+}
+
+// IsConcurrencyLimited 是否是被自适应限流拒绝的错误
+func IsConcurrencyLimited(err error) bool {
+	e, ok := errorx.From(err)
+	return ok && e.Code.Code == errorx.ErrConcurrencyLimited.Code
+}
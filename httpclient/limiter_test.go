@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	l := NewAdaptiveLimiter(&AIMD{Min: 2, Max: 50}, 10)
+
+	// 占满额度后再申请会被拒绝
+	releases := make([]func(time.Duration, bool), 0, 10)
+	for i := 0; i < 10; i++ {
+		release, ok := l.Acquire()
+		if !ok {
+			t.Fatalf("acquire %d rejected", i)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := l.Acquire(); ok {
+		t.Fatal("acquire over limit should be rejected")
+	}
+
+	// 满载且成功：加性增长
+	for _, release := range releases {
+		release(10*time.Millisecond, false)
+	}
+	if got := l.Limit(); got <= 10 {
+		t.Fatalf("limit should grow, got %d", got)
+	}
+
+	// 持续过载：乘性下降到下限
+	for i := 0; i < 100; i++ {
+		release, ok := l.Acquire()
+		if !ok {
+			t.Fatalf("acquire %d rejected", i)
+		}
+		release(time.Second, true)
+	}
+	if got := l.Limit(); got != 2 {
+		t.Fatalf("limit should shrink to min, got %d", got)
+	}
+	if got := l.Inflight(); got != 0 {
+		t.Fatalf("inflight = %d", got)
+	}
+}
+
+// 调用方取消或调用方 ctx 到期不是过载信号，上限不应下降
+func TestAdaptiveLimitIgnoresCallerCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL), WithRetry(0, nil, nil),
+		WithAdaptiveLimit(func() LimitAlgorithm { return &AIMD{} }, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	for i := 0; i < 10; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		if _, err := c.GetJSON(ctx, "/", nil); err == nil {
+			t.Fatal("want cancel error")
+		}
+		cancel()
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		if _, err := c.GetJSON(ctx, "/", nil); err == nil {
+			t.Fatal("want timeout error")
+		}
+		cancel()
+	}
+	if got := c.Limits()[host]; got < 10 {
+		t.Fatalf("limit dropped to %d on caller cancellations", got)
+	}
+
+	// 后端返回 503 仍然收缩
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	for i := 0; i < 5; i++ {
+		_, _ = c.GetJSON(context.Background(), unavailable.URL, nil)
+	}
+	if got := c.Limits()[strings.TrimPrefix(unavailable.URL, "http://")]; got >= 10 {
+		t.Fatalf("limit should shrink on 503, got %d", got)
+	}
+}
+
+// 后端卡住、触发 client 自己的超时是过载信号，上限必须下降
+func TestAdaptiveLimitDropsOnClientTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL), WithRetry(0, nil, nil), WithDefaultTimeout(10*time.Millisecond),
+		WithAdaptiveLimit(func() LimitAlgorithm { return &AIMD{} }, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		_, err := c.GetJSON(context.Background(), "/", nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("want deadline exceeded, got %v", err)
+		}
+	}
+	if got := c.Limits()[strings.TrimPrefix(srv.URL, "http://")]; got >= 10 {
+		t.Fatalf("limit should shrink on client timeouts, got %d", got)
+	}
+}
@@ -0,0 +1,346 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/tracex"
+)
+
+const defaultMaxPages = 1000
+
+// Paginator 描述一种分页方式：如何解析一页、如何构造下一页
+type Paginator[T any] struct {
+	// decode 解析一页响应，返回本页数据和游标（不使用游标的方式返回空串）
+	decode func(resp *http.Response, body []byte) ([]T, string, error)
+	// next 根据本页结果构造下一页请求，返回 nil 表示结束
+	next func(cur *Request, resp *http.Response, n int, cursor string) *Request
+	// at 不依赖上一页直接构造第 i 页（从 0 开始），非 nil 时支持并发预取
+	at func(first *Request, i int) *Request
+}
+
+// ByCursor 游标在响应体中：decode 返回下一页游标，作为 query 参数 param 发送，游标为空时结束
+func ByCursor[T any](param string, decode func(body []byte) (items []T, cursor string, err error)) Paginator[T] {
+	return Paginator[T]{
+		decode: func(_ *http.Response, body []byte) ([]T, string, error) {
+			return decode(body)
+		},
+		next: func(cur *Request, _ *http.Response, _ int, cursor string) *Request {
+			if cursor == "" {
+				return nil
+			}
+			return withQueryParam(cur, param, cursor)
+		},
+	}
+}
+
+// ByPage 页码分页：query 参数 param 从 start 开始递增，返回空页时结束
+func ByPage[T any](param string, start int, decode func(body []byte) ([]T, error)) Paginator[T] {
+	return Paginator[T]{
+		decode: decodeItems(decode),
+		next: func(cur *Request, _ *http.Response, n int, _ string) *Request {
+			if n == 0 {
+				return nil
+			}
+			// 第一页的请求可以不带 param，此时就是 start 页
+			page := start
+			if v := cur.Query.Get(param); v != "" {
+				page, _ = strconv.Atoi(v)
+			}
+			return withQueryParam(cur, param, strconv.Itoa(page+1))
+		},
+		at: func(first *Request, i int) *Request {
+			return withQueryParam(first, param, strconv.Itoa(start+i))
+		},
+	}
+}
+
+// ByOffset offset/limit 分页：每页 limit 条，不足 limit 条时结束
+func ByOffset[T any](offsetParam, limitParam string, limit int, decode func(body []byte) ([]T, error)) Paginator[T] {
+	at := func(first *Request, i int) *Request {
+		r := withQueryParam(first, offsetParam, strconv.Itoa(i*limit))
+		return withQueryParam(r, limitParam, strconv.Itoa(limit))
+	}
+	return Paginator[T]{
+		decode: decodeItems(decode),
+		next: func(cur *Request, _ *http.Response, n int, _ string) *Request {
+			if n == 0 || n < limit {
+				return nil
+			}
+			offset, _ := strconv.Atoi(cur.Query.Get(offsetParam))
+			return withQueryParam(cur, offsetParam, strconv.Itoa(offset+n))
+		},
+		at: at,
+	}
+}
+
+// ByLink RFC 5988 Link 头：跟随 rel="next"，没有时结束
+func ByLink[T any](decode func(body []byte) ([]T, error)) Paginator[T] {
+	return Paginator[T]{
+		decode: decodeItems(decode),
+		next: func(cur *Request, resp *http.Response, _ int, _ string) *Request {
+			next := nextLink(resp)
+			if next == "" {
+				return nil
+			}
+			r := cloneRequest(cur)
+			r.Path = next
+			r.Query = nil // next 链接自带 query
+			return r
+		},
+	}
+}
+
+type pageConfig struct {
+	maxPages int
+	prefetch int
+}
+
+type PageOption func(*pageConfig)
+
+// WithMaxPages 最多拉取的页数，之后确实还有数据时返回 errorx.ErrPageLimitExceeded，默认 1000
+func WithMaxPages(n int) PageOption {
+	return func(c *pageConfig) { c.maxPages = n }
+}
+
+// WithPrefetch 预取页数：
+//   - 游标 / Link 分页依赖上一页，最多提前拉取 1 页
+//   - 页码 / offset 分页最多 n 页并发拉取
+func WithPrefetch(n int) PageOption {
+	return func(c *pageConfig) { c.prefetch = n }
+}
+
+// Paginate 按 p 描述的方式遍历所有页，逐条返回数据；
+// 所有页的请求都挂在同一个 "http_paginate" span 下，遇到错误时返回 (零值, err) 后结束。
+//
+//	for item, err := range httpclient.Paginate(ctx, cli, req, httpclient.ByLink(decode)) {
+//		if err != nil { ... }
+//	}
+func Paginate[T any](ctx context.Context, c *Client, req *Request, p Paginator[T], opts ...PageOption) iter.Seq2[T, error] {
+	cfg := pageConfig{maxPages: defaultMaxPages}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.maxPages <= 0 {
+		cfg.maxPages = defaultMaxPages
+	}
+
+	return func(yield func(T, error) bool) {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		ctx, _ := tracex.StartSpan(ctx, "http_paginate")
+		var spanErr error
+		defer func() {
+			tracex.EndSpan(ctx, spanErr)
+		}()
+		// 调用方提前 break 时取消预取中的请求
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		fail := func(err error) {
+			spanErr = err
+			var zero T
+			yield(zero, err)
+		}
+
+		pages := newPageFetcher(ctx, c, p, cfg, req)
+		for i := 0; ; i++ {
+			res := pages.get()
+			if res.err != nil {
+				fail(res.err)
+				return
+			}
+			for _, item := range res.items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if res.next == nil {
+				return
+			}
+			if i+1 >= cfg.maxPages {
+				// 页码 / offset 分页要拉到空页才知道结束，恰好 maxPages 页时多探一页确认
+				extra := pages.probe()
+				switch {
+				case extra.err != nil:
+					fail(extra.err)
+				case len(extra.items) > 0 || extra.next != nil:
+					fail(errorx.New(errorx.ErrPageLimitExceeded,
+						errorx.WithMessage(fmt.Sprintf("more than %d pages", cfg.maxPages))))
+				}
+				return
+			}
+		}
+	}
+}
+
+type pageResult[T any] struct {
+	items []T
+	next  *Request
+	err   error
+}
+
+// pageFetcher 按顺序产出每一页，内部负责预取
+type pageFetcher[T any] struct {
+	ctx    context.Context
+	c      *Client
+	p      Paginator[T]
+	cfg    pageConfig
+	first  *Request
+	next   *Request
+	idx    int // 已发起的页数
+	window []chan pageResult[T]
+}
+
+func newPageFetcher[T any](ctx context.Context, c *Client, p Paginator[T], cfg pageConfig, first *Request) *pageFetcher[T] {
+	f := &pageFetcher[T]{ctx: ctx, c: c, p: p, cfg: cfg, first: first, next: first}
+	f.fill()
+	return f
+}
+
+// fill 在预取窗口内发起请求
+func (f *pageFetcher[T]) fill() {
+	size := 1
+	if f.p.at != nil && f.cfg.prefetch > 1 {
+		size = f.cfg.prefetch
+	}
+	for len(f.window) < size && f.idx < f.cfg.maxPages {
+		var r *Request
+		switch {
+		case f.p.at != nil:
+			r = f.p.at(f.first, f.idx)
+		case f.idx == 0:
+			r = f.first
+		default:
+			// 依赖上一页，只能等上一页结果
+			return
+		}
+		f.idx++
+		ch := make(chan pageResult[T], 1)
+		f.window = append(f.window, ch)
+		go func() { ch <- f.fetch(r) }()
+	}
+}
+
+// get 取下一页结果，并按配置继续预取
+func (f *pageFetcher[T]) get() pageResult[T] {
+	var res pageResult[T]
+	if len(f.window) == 0 {
+		// 游标 / Link 分页未开启预取
+		f.idx++
+		res = f.fetch(f.next)
+	} else {
+		res = <-f.window[0]
+		f.window = f.window[1:]
+	}
+	if res.err != nil || res.next == nil {
+		return res
+	}
+	if f.p.at != nil {
+		f.fill()
+		return res
+	}
+	f.next = res.next
+	if f.cfg.prefetch > 0 && f.idx < f.cfg.maxPages {
+		// 调用方消费本页时提前拉取下一页
+		f.idx++
+		ch := make(chan pageResult[T], 1)
+		f.window = append(f.window, ch)
+		go func() { ch <- f.fetch(res.next) }()
+	}
+	return res
+}
+
+// probe 拉取 maxPages 之后的一页，只用来判断是否超出上限
+func (f *pageFetcher[T]) probe() pageResult[T] {
+	if f.p.at != nil {
+		return f.fetch(f.p.at(f.first, f.idx))
+	}
+	return f.fetch(f.next)
+}
+
+func (f *pageFetcher[T]) fetch(r *Request) pageResult[T] {
+	var body []byte
+	resp, err := f.c.Do(f.ctx, r, &body)
+	if err != nil {
+		return pageResult[T]{err: err}
+	}
+	items, cursor, err := f.p.decode(resp, body)
+	if err != nil {
+		return pageResult[T]{err: err}
+	}
+	return pageResult[T]{items: items, next: f.p.next(r, resp, len(items), cursor)}
+}
+
+// ---------- 小工具 ----------
+
+func decodeItems[T any](decode func(body []byte) ([]T, error)) func(*http.Response, []byte) ([]T, string, error) {
+	return func(_ *http.Response, body []byte) ([]T, string, error) {
+		items, err := decode(body)
+		return items, "", err
+	}
+}
+
+// cloneRequest 复制 Request，Query / Headers 深拷贝
+func cloneRequest(r *Request) *Request {
+	cp := *r
+	cp.Headers = cloneHeader(r.Headers)
+	if r.Query != nil {
+		cp.Query = make(url.Values, len(r.Query))
+		for k, vs := range r.Query {
+			cp.Query[k] = append([]string(nil), vs...)
+		}
+	}
+	return &cp
+}
+
+func withQueryParam(r *Request, k, v string) *Request {
+	cp := cloneRequest(r)
+	if cp.Query == nil {
+		cp.Query = make(url.Values)
+	}
+	cp.Query.Set(k, v)
+	return cp
+}
+
+// nextLink 解析 Link: <https://api/x?page=2>; rel="next"，相对地址基于本次请求 URL
+func nextLink(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	for _, header := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(header, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+			for _, param := range parts[1:] {
+				k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(k, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(v, `"`)) {
+					if !strings.EqualFold(rel, "next") {
+						continue
+					}
+					if resp.Request != nil && resp.Request.URL != nil {
+						if u, err := resp.Request.URL.Parse(target); err == nil {
+							return u.String()
+						}
+					}
+					return target
+				}
+			}
+		}
+	}
+	return ""
+}
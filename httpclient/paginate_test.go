@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/imattdu/orbit/errorx"
+)
+
+func decodeInts(body []byte) ([]int, error) {
+	var out []int
+	err := json.Unmarshal(body, &out)
+	return out, err
+}
+
+func TestPaginate(t *testing.T) {
+	const total = 23
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		items := []int{}
+		for i := page * 5; i < (page+1)*5 && i < total; i++ {
+			items = append(items, i)
+		}
+		if (page+1)*5 < total {
+			w.Header().Set("Link", fmt.Sprintf(`</items?page=%d>; rel="next"`, page+1))
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	defer srv.Close()
+
+	cli, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		p    Paginator[int]
+		opts []PageOption
+	}{
+		{name: "link", p: ByLink(decodeInts)},
+		{name: "link_prefetch", p: ByLink(decodeInts), opts: []PageOption{WithPrefetch(1)}},
+		{name: "page", p: ByPage("page", 0, decodeInts)},
+		{name: "page_prefetch", p: ByPage("page", 0, decodeInts), opts: []PageOption{WithPrefetch(3)}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []int
+			for v, err := range Paginate(context.Background(), cli, &Request{Method: http.MethodGet, Path: "/items"}, tc.p, tc.opts...) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, v)
+			}
+			if len(got) != total {
+				t.Fatalf("got %d items, want %d", len(got), total)
+			}
+			for i, v := range got {
+				if v != i {
+					t.Fatalf("item %d = %d", i, v)
+				}
+			}
+		})
+	}
+
+	var lastErr error
+	for _, err := range Paginate(context.Background(), cli, &Request{Method: http.MethodGet, Path: "/items"}, ByLink(decodeInts), WithMaxPages(2)) {
+		lastErr = err
+	}
+	if e, ok := errorx.From(lastErr); !ok || e.Code.Code != errorx.ErrPageLimitExceeded.Code {
+		t.Fatalf("want page limit error, got %v", lastErr)
+	}
+}
+
+// 页码从 start 开始；恰好 maxPages 页时不算超限，之后还有数据才报错
+func TestPaginateByPageStartAndLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page < 1 {
+			t.Errorf("page = %q", r.URL.Query().Get("page"))
+		}
+		items := []int{}
+		if page <= 3 {
+			items = []int{page*10 + 1, page*10 + 2}
+		}
+		_ = json.NewEncoder(w).Encode(items)
+	}))
+	defer srv.Close()
+	cli, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := ByPage("page", 1, decodeInts)
+	if next := p.next(&Request{}, nil, 2, ""); next == nil || next.Query.Get("page") != "2" {
+		t.Fatalf("next of the start page = %+v", next)
+	}
+
+	collect := func(opts ...PageOption) ([]int, error) {
+		var got []int
+		for v, err := range Paginate(context.Background(), cli, &Request{Method: http.MethodGet, Path: "/"}, p, opts...) {
+			if err != nil {
+				return got, err
+			}
+			got = append(got, v)
+		}
+		return got, nil
+	}
+	for _, opts := range [][]PageOption{nil, {WithMaxPages(3)}, {WithMaxPages(3), WithPrefetch(2)}} {
+		got, err := collect(opts...)
+		if err != nil || fmt.Sprint(got) != "[11 12 21 22 31 32]" {
+			t.Fatalf("got %v err=%v", got, err)
+		}
+	}
+	if _, err := collect(WithMaxPages(2)); !hasCode(err, errorx.ErrPageLimitExceeded) {
+		t.Fatalf("want page limit error, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProxyWithAuthAndBypass(t *testing.T) {
+	// 普通 http 目标经代理时，请求行是完整 URL，Proxy-Authorization 带上账号密码
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := parseProxyAuth(r); !ok || user != "bob" || pass != "s3cret" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		_, _ = w.Write([]byte(`"via proxy ` + r.URL.Host + `"`))
+	}))
+	defer proxy.Close()
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`"direct"`))
+	}))
+	defer direct.Close()
+	_, directPort, _ := net.SplitHostPort(strings.TrimPrefix(direct.URL, "http://"))
+
+	var attempts []CallAttempt
+	c, err := New(withNopLogger(), WithCurlOnFailure(),
+		WithProxy(ProxyConfig{
+			URL:     strings.Replace(proxy.URL, "http://", "http://bob:s3cret@", 1),
+			NoProxy: []string{"internal.test"},
+		}),
+		WithDNSCache(DNSCacheConfig{Resolver: &countingResolver{addrs: []string{"127.0.0.1"}}}),
+		WithStatsHook(func(_ context.Context, s *CallStats) { attempts = append(attempts, s.AttemptsLog...) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if _, err := c.GetJSON(context.Background(), "http://upstream.test/x", &got); err != nil || got != "via proxy upstream.test" {
+		t.Fatalf("proxied: %q %v", got, err)
+	}
+	if _, err := c.GetJSON(context.Background(), "http://api.internal.test:"+directPort+"/x", &got); err != nil || got != "direct" {
+		t.Fatalf("bypass: %q %v", got, err)
+	}
+	if len(attempts) != 2 || attempts[0].Proxy != proxy.URL || attempts[1].Proxy != "" {
+		t.Fatalf("attempt proxies: %+v", attempts)
+	}
+	if strings.Contains(c.curlCommand(httptestRequest(t, "http://upstream.test/x"), nil, false, 0), "s3cret") {
+		t.Fatal("proxy password leaked into curl command")
+	}
+}
+
+func parseProxyAuth(r *http.Request) (string, string, bool) {
+	req := &http.Request{Header: http.Header{"Authorization": r.Header["Proxy-Authorization"]}}
+	return req.BasicAuth()
+}
+
+func httptestRequest(t *testing.T, u string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
@@ -0,0 +1,513 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/logx"
+)
+
+// RegistryConfig 配置文件结构（YAML / JSON），文件内容支持 ${ENV} 环境变量展开：
+//
+//	clients:
+//	  user-service:
+//	    base_urls: ["http://10.0.0.1:8080", "http://10.0.0.2:8080"]
+//	    timeout: 2s
+//	    retry: {max_attempts: 3, idempotent_only: true}
+//	    service: {code: 101, message: user-service}
+//	    log: {app_name: user-service, dir: logs, level: info}
+type RegistryConfig struct {
+	Clients map[string]ClientConfig `yaml:"clients" json:"clients"`
+}
+
+// ClientConfig 单个 client 的声明，零值字段使用 New 的默认值
+type ClientConfig struct {
+	BaseURL  string   `yaml:"base_url" json:"base_url"`
+	BaseURLs []string `yaml:"base_urls" json:"base_urls"`
+
+	Timeout             time.Duration `yaml:"timeout" json:"timeout"`
+	DialTimeout         time.Duration `yaml:"dial_timeout" json:"dial_timeout"`
+	ReadWriteTimeout    time.Duration `yaml:"read_write_timeout" json:"read_write_timeout"`
+	TLSHandshakeTimeout time.Duration `yaml:"tls_handshake_timeout" json:"tls_handshake_timeout"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout" json:"idle_conn_timeout"`
+
+	MaxIdleConns        int `yaml:"max_idle_conns" json:"max_idle_conns"`
+	MaxIdleConnsPerHost int `yaml:"max_idle_conns_per_host" json:"max_idle_conns_per_host"`
+
+	UnixSocket string `yaml:"unix_socket" json:"unix_socket"`
+	H2C        bool   `yaml:"h2c" json:"h2c"`
+	DNSCache   bool   `yaml:"dns_cache" json:"dns_cache"` // 系统 resolver + 默认 TTL 缓存
+
+	Proxy *ProxyConfig `yaml:"proxy" json:"proxy"` // 账号密码建议用 ${ENV} 引用
+
+	Retry             RetryConfig       `yaml:"retry" json:"retry"`
+	IdempotencyHeader string            `yaml:"idempotency_header" json:"idempotency_header"`
+	Cookies           bool              `yaml:"cookies" json:"cookies"` // 开启 client 级 cookie jar
+	Service           *errorx.CodeEntry `yaml:"service" json:"service"`
+	Log               *LogConfig        `yaml:"log" json:"log"`
+}
+
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts" json:"max_attempts"`
+	BackoffBase    time.Duration `yaml:"backoff_base" json:"backoff_base"`
+	BackoffMax     time.Duration `yaml:"backoff_max" json:"backoff_max"`
+	IdempotentOnly bool          `yaml:"idempotent_only" json:"idempotent_only"` // 非幂等方法只有带幂等键才重试
+}
+
+type LogConfig struct {
+	AppName    string `yaml:"app_name" json:"app_name"`
+	Dir        string `yaml:"dir" json:"dir"`
+	Level      string `yaml:"level" json:"level"` // debug / info / warn / error
+	MaxBackups int    `yaml:"max_backups" json:"max_backups"`
+	QueueSize  int    `yaml:"queue_size" json:"queue_size"`
+	Console    bool   `yaml:"console" json:"console"`
+}
+
+// Options 把声明转换成 New 的 Option；配置了 Log 时新建一个由 client 负责关闭的 logger
+func (cc ClientConfig) Options() ([]Option, error) {
+	return cc.options(nil)
+}
+
+// options logger 不为 nil 时使用它（由调用方管理生命周期），否则按 Log 新建
+func (cc ClientConfig) options(logger logx.Logger) ([]Option, error) {
+	opts := []Option{WithBaseURL(cc.BaseURL), WithBaseURLs(cc.BaseURLs...)}
+	setDuration := func(v time.Duration, f func(c *Config, v time.Duration)) {
+		if v > 0 {
+			opts = append(opts, func(c *Config) { f(c, v) })
+		}
+	}
+	setDuration(cc.Timeout, func(c *Config, v time.Duration) { c.DefaultTimeout = v })
+	setDuration(cc.DialTimeout, func(c *Config, v time.Duration) { c.DialTimeout = v })
+	setDuration(cc.ReadWriteTimeout, func(c *Config, v time.Duration) { c.ReadWriteTimeout = v })
+	setDuration(cc.TLSHandshakeTimeout, func(c *Config, v time.Duration) { c.TLSHandshakeTimeout = v })
+	setDuration(cc.IdleConnTimeout, func(c *Config, v time.Duration) { c.IdleConnTimeout = v })
+	if cc.MaxIdleConns > 0 {
+		opts = append(opts, func(c *Config) { c.MaxIdleConns = cc.MaxIdleConns })
+	}
+	if cc.MaxIdleConnsPerHost > 0 {
+		opts = append(opts, func(c *Config) { c.MaxIdleConnsPerHost = cc.MaxIdleConnsPerHost })
+	}
+
+	if cc.UnixSocket != "" {
+		opts = append(opts, WithUnixSocket(cc.UnixSocket))
+	}
+	if cc.H2C {
+		opts = append(opts, WithH2C())
+	}
+	if cc.DNSCache {
+		opts = append(opts, WithDNSCache(DNSCacheConfig{}))
+	}
+	if cc.Proxy != nil {
+		opts = append(opts, WithProxy(*cc.Proxy))
+	}
+
+	if cc.Retry.MaxAttempts > 0 {
+		var backoff BackoffFunc
+		if cc.Retry.BackoffBase > 0 {
+			maxWait := cc.Retry.BackoffMax
+			if maxWait <= 0 {
+				maxWait = 2 * time.Second
+			}
+			backoff = ExponentialBackoff(cc.Retry.BackoffBase, maxWait)
+		}
+		opts = append(opts, WithRetry(cc.Retry.MaxAttempts, nil, backoff))
+	}
+	if cc.IdempotencyHeader != "" {
+		opts = append(opts, WithIdempotencyHeader(cc.IdempotencyHeader))
+	}
+	if cc.Retry.IdempotentOnly {
+		opts = append(opts, WithRetryPolicy(RetryIdempotent(nil)))
+	}
+	if cc.Cookies {
+		opts = append(opts, WithCookieJar(nil))
+	}
+	if cc.Service != nil {
+		opts = append(opts, WithService(*cc.Service))
+	}
+
+	switch {
+	case logger != nil:
+		opts = append(opts, WithLogger(logger))
+	case cc.Log != nil:
+		lc, err := cc.Log.resolve()
+		if err != nil {
+			return nil, err
+		}
+		l, err := lc.newLogger()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithLogger(l), func(c *Config) { c.ownLogger = true })
+	}
+	return opts, nil
+}
+
+// defaultLogConfig 与 New 未设置 logger 时创建的默认 logger 一致
+var defaultLogConfig = LogConfig{AppName: "http", Dir: "logs", Level: "info", MaxBackups: 10, QueueSize: 10000}
+
+// resolve 补齐默认值并把目录转成绝对路径，结果相同的配置写的是同一组文件
+func (lc LogConfig) resolve() (LogConfig, error) {
+	var level slog.Level
+	if lc.Level != "" {
+		if err := level.UnmarshalText([]byte(lc.Level)); err != nil {
+			return LogConfig{}, err
+		}
+	}
+	lc.Level = level.String()
+	if lc.AppName == "" {
+		lc.AppName = "http"
+	}
+	if lc.Dir == "" {
+		lc.Dir = "logs"
+	}
+	if lc.QueueSize <= 0 {
+		lc.QueueSize = 10000
+	}
+	dir, err := filepath.Abs(lc.Dir)
+	if err != nil {
+		return LogConfig{}, err
+	}
+	lc.Dir = dir
+	return lc, nil
+}
+
+// newLogger 按 resolve 过的配置创建 logger
+func (lc LogConfig) newLogger() (logx.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(lc.Level)); err != nil {
+		return nil, err
+	}
+	return logx.New(logx.Config{
+		AppName:        lc.AppName,
+		Level:          level,
+		LogDir:         lc.Dir,
+		ConsoleEnabled: lc.Console,
+		MaxBackups:     lc.MaxBackups,
+		QueueSize:      lc.QueueSize,
+	})
+}
+
+// logFiles 一组日志文件：同一目录下的同一个 app
+type logFiles struct {
+	dir, app string
+}
+
+// sharedLogger registry 内共享的 logger，同一组文件只有一个 handler 在写、切分和清理
+type sharedLogger struct {
+	cfg    LogConfig
+	logger logx.Logger
+}
+
+// loggerSet 一次 Apply 用到的 logger：沿用上一次配置相同的，其余新建
+type loggerSet struct {
+	prev map[logFiles]*sharedLogger
+	next map[logFiles]*sharedLogger
+}
+
+// get 取 lc 对应的 logger：同一组文件只允许一份配置；
+// 已在使用的文件不能换配置，否则新旧两个 handler 会在旧 client 下线前同时切分、压缩、清理这些文件，
+// 需要调整级别时用 logx 的运行时级别控制
+func (s *loggerSet) get(lc LogConfig) (logx.Logger, error) {
+	lc, err := lc.resolve()
+	if err != nil {
+		return nil, err
+	}
+	key := logFiles{dir: lc.Dir, app: lc.AppName}
+	if sl, ok := s.next[key]; ok {
+		if sl.cfg != lc {
+			return nil, fmt.Errorf("log %s in %s configured twice with different settings", lc.AppName, lc.Dir)
+		}
+		return sl.logger, nil
+	}
+	if sl, ok := s.prev[key]; ok {
+		if sl.cfg != lc {
+			return nil, fmt.Errorf("log %s in %s is in use, its settings cannot change on reload", lc.AppName, lc.Dir)
+		}
+		s.next[key] = sl
+		return sl.logger, nil
+	}
+	l, err := lc.newLogger()
+	if err != nil {
+		return nil, err
+	}
+	s.next[key] = &sharedLogger{cfg: lc, logger: l}
+	return l, nil
+}
+
+// unused prev 里没有被 next 沿用的 logger；reverse 为 true 时反过来取 next 里新建的
+func (s *loggerSet) unused(reverse bool) []logx.Logger {
+	from, in := s.prev, s.next
+	if reverse {
+		from, in = s.next, s.prev
+	}
+	var out []logx.Logger
+	for key, sl := range from {
+		if in[key] != sl {
+			out = append(out, sl.logger)
+		}
+	}
+	return out
+}
+
+// Registry 按名字管理 client，配置变化时只重建受影响的 client；
+// 旧 client 不会被打断，已经拿到它的调用照常完成。
+// 配置里的 client 按日志目录 + app 共享 logger（没写 log 的共享默认 logger），
+// 不再使用的 logger 在旧 client 关闭后关闭；仍在使用的日志文件不能在重载时更换配置
+type Registry struct {
+	applyMu sync.Mutex                 // 串行化 Apply，避免并发重载互相覆盖、泄漏新建的 client
+	loggers map[logFiles]*sharedLogger // applyMu 保护
+
+	mu      sync.RWMutex
+	clients map[string]*registryEntry
+
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+type registryEntry struct {
+	cfg    ClientConfig
+	client *Client
+	manual bool // Register 注册的，配置重载时保留
+}
+
+func NewRegistry() *Registry {
+	return &Registry{clients: make(map[string]*registryEntry)}
+}
+
+// LoadFile 读取配置文件并应用，记住路径供 Watch 使用
+func (r *Registry) LoadFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg RegistryConfig
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(data))), &cfg); err != nil {
+		return fmt.Errorf("parse httpclient config %s: %w", path, err)
+	}
+	if err := r.Apply(cfg); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.path, r.modTime, r.size = path, info.ModTime(), info.Size()
+	r.mu.Unlock()
+	return nil
+}
+
+// Apply 应用一份完整配置：新增 / 变化的 client 重建，删除的下线，未变化的保持原实例。
+// Register 手动注册的 client 不受配置影响（同名时以手动注册的为准）。
+// 任何一个 client 构建失败时整体不生效，已经新建的 client 会被关闭
+func (r *Registry) Apply(cfg RegistryConfig) error {
+	r.applyMu.Lock()
+	defer r.applyMu.Unlock()
+
+	r.mu.RLock()
+	old := maps.Clone(r.clients)
+	r.mu.RUnlock()
+
+	names := slices.Sorted(maps.Keys(cfg.Clients))
+	next := make(map[string]*registryEntry, len(names))
+	loggers := &loggerSet{prev: r.loggers, next: make(map[logFiles]*sharedLogger)}
+	var built []*Client
+	for _, name := range names {
+		cc := cfg.Clients[name]
+		e, ok := old[name]
+		if ok && e.manual {
+			continue
+		}
+		cli, err := func() (*Client, error) {
+			// 没写 log 的 client 使用和 New 相同的默认 logger，同样在 registry 内共享；
+			// 未变化的 client 也要登记它的 logger，否则会被当成不再使用而关闭
+			lc := defaultLogConfig
+			if cc.Log != nil {
+				lc = *cc.Log
+			}
+			logger, err := loggers.get(lc)
+			if err != nil {
+				return nil, err
+			}
+			if ok && reflect.DeepEqual(e.cfg, cc) {
+				return nil, nil
+			}
+			return newFromConfig(cc, logger)
+		}()
+		if err != nil {
+			retire(built, loggers.unused(true))
+			return fmt.Errorf("httpclient %q: %w", name, err)
+		}
+		if cli == nil {
+			continue
+		}
+		built = append(built, cli)
+		next[name] = &registryEntry{cfg: cc, client: cli}
+	}
+
+	// 加锁后以当前的表为准：未变化的沿用，期间 Register 的保留
+	var retired []*Client
+	r.mu.Lock()
+	for name, e := range r.clients {
+		_, declared := cfg.Clients[name]
+		switch {
+		case e.manual:
+			if n, ok := next[name]; ok {
+				retired = append(retired, n.client)
+			}
+			next[name] = e
+		case declared && next[name] == nil && reflect.DeepEqual(e.cfg, cfg.Clients[name]):
+			next[name] = e
+		default:
+			retired = append(retired, e.client)
+		}
+	}
+	r.clients = next
+	r.mu.Unlock()
+
+	stale := loggers.unused(false)
+	r.loggers = loggers.next
+	retire(retired, stale)
+	return nil
+}
+
+// newFromConfig 按声明创建 client，使用 registry 共享的 logger（client 不负责关闭）
+func newFromConfig(cc ClientConfig, logger logx.Logger) (*Client, error) {
+	opts, err := cc.options(logger)
+	if err != nil {
+		return nil, err
+	}
+	return New(opts...)
+}
+
+// retireTimeout 下线的 client 最多等待在途请求的时间
+const retireTimeout = time.Minute
+
+// retireClient 后台关闭被替换的 client，进行中的请求不受影响
+func retireClient(c *Client) {
+	retire([]*Client{c}, nil)
+}
+
+// retire 后台关闭 clients，全部关闭后再关闭它们用过、已不再需要的 loggers
+func retire(clients []*Client, loggers []logx.Logger) {
+	if len(clients) == 0 && len(loggers) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), retireTimeout)
+		defer cancel()
+		var wg sync.WaitGroup
+		for _, c := range clients {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := c.Close(ctx); err != nil {
+					log.Println("httpclient retire client failed:", err)
+				}
+			}()
+		}
+		wg.Wait()
+		for _, l := range loggers {
+			if err := l.Close(ctx); err != nil {
+				log.Println("httpclient close logger failed:", err)
+			}
+		}
+	}()
+}
+
+// Register 手动注册（或替换）一个 client，之后的配置重载不会替换或下线它
+func (r *Registry) Register(name string, c *Client) {
+	r.mu.Lock()
+	old := r.clients[name]
+	r.clients[name] = &registryEntry{client: c, manual: true}
+	r.mu.Unlock()
+	if old != nil && old.client != c {
+		retireClient(old.client)
+	}
+}
+
+// Get 按名字取 client，不存在时返回 nil；
+// 配置会被热更新，调用方应每次使用时 Get，不要长期持有
+func (r *Registry) Get(name string) *Client {
+	c, _ := r.Lookup(name)
+	return c
+}
+
+// Lookup 按名字取 client
+func (r *Registry) Lookup(name string) (*Client, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.clients[name]
+	if !ok {
+		return nil, false
+	}
+	return e.client, true
+}
+
+// Watch 每隔 interval 检查配置文件，变化时重新加载，直到 ctx 结束
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			r.mu.RLock()
+			path, modTime, size := r.path, r.modTime, r.size
+			r.mu.RUnlock()
+			if path == "" {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil || (info.ModTime().Equal(modTime) && info.Size() == size) {
+				continue
+			}
+			if err := r.LoadFile(path); err != nil {
+				log.Println("httpclient reload config failed:", err)
+			}
+		}
+	}()
+}
+
+// -------------------- 全局默认 registry --------------------
+
+var defaultRegistry = NewRegistry()
+
+// LoadConfig 加载配置到全局 registry（建议在 main 里调用一次）
+func LoadConfig(path string) error {
+	return defaultRegistry.LoadFile(path)
+}
+
+// WatchConfig 监听全局 registry 的配置文件
+func WatchConfig(ctx context.Context, interval time.Duration) {
+	defaultRegistry.Watch(ctx, interval)
+}
+
+// Register 注册到全局 registry
+func Register(name string, c *Client) {
+	defaultRegistry.Register(name, c)
+}
+
+// Get 从全局 registry 按名字取 client，未注册时返回 nil
+func Get(name string) *Client {
+	return defaultRegistry.Get(name)
+}
@@ -0,0 +1,251 @@
+package httpclient
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func registryCfg(t *testing.T, clients map[string]string) RegistryConfig {
+	t.Helper()
+	cfg := RegistryConfig{Clients: map[string]ClientConfig{}}
+	for name, base := range clients {
+		cfg.Clients[name] = ClientConfig{BaseURL: base, Log: &LogConfig{Dir: t.TempDir()}}
+	}
+	return cfg
+}
+
+func isClosed(c *Client) bool {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+	return c.closed
+}
+
+func waitClosed(t *testing.T, c *Client) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !isClosed(c) {
+		if time.Now().After(deadline) {
+			t.Fatal("client not retired")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRegistryReload(t *testing.T) {
+	r := NewRegistry()
+	cfg := registryCfg(t, map[string]string{"user": "http://user", "order": "http://order"})
+	if err := r.Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+	user, order := r.Get("user"), r.Get("order")
+
+	// user 不变、order 变化、pay 新增
+	next := RegistryConfig{Clients: map[string]ClientConfig{
+		"user":  cfg.Clients["user"],
+		"order": {BaseURL: "http://order-v2", Log: cfg.Clients["order"].Log},
+		"pay":   registryCfg(t, map[string]string{"pay": "http://pay"}).Clients["pay"],
+	}}
+	if err := r.Apply(next); err != nil {
+		t.Fatal(err)
+	}
+	if r.Get("user") != user {
+		t.Fatal("unchanged client rebuilt")
+	}
+	if r.Get("order") == order || r.Get("pay") == nil {
+		t.Fatal("changed / added client not applied")
+	}
+	waitClosed(t, order)
+	if isClosed(user) {
+		t.Fatal("unchanged client closed")
+	}
+
+	// 删除 pay
+	delete(next.Clients, "pay")
+	pay := r.Get("pay")
+	if err := r.Apply(next); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Lookup("pay"); ok {
+		t.Fatal("removed client still registered")
+	}
+	waitClosed(t, pay)
+}
+
+func TestRegistryFailedReload(t *testing.T) {
+	r := NewRegistry()
+	cfg := registryCfg(t, map[string]string{"user": "http://user"})
+	if err := r.Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+	user := r.Get("user")
+
+	bad := registryCfg(t, map[string]string{"a-new": "http://new", "user": "http://user"})
+	bad.Clients["user"] = cfg.Clients["user"]
+	bad.Clients["z-bad"] = ClientConfig{Proxy: &ProxyConfig{URL: "ftp://proxy"}, Log: &LogConfig{Dir: t.TempDir()}}
+	if err := r.Apply(bad); err == nil {
+		t.Fatal("invalid config applied")
+	}
+	if r.Get("user") != user || isClosed(user) {
+		t.Fatal("failed reload touched existing client")
+	}
+	if _, ok := r.Lookup("a-new"); ok {
+		t.Fatal("partial config applied")
+	}
+}
+
+func TestRegistryRegisterSurvivesReload(t *testing.T) {
+	r := NewRegistry()
+	manual, err := New(withNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Register("manual", manual)
+	r.Register("user", manual)
+
+	if err := r.Apply(registryCfg(t, map[string]string{"user": "http://user", "order": "http://order"})); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Apply(registryCfg(t, map[string]string{"order": "http://order"})); err != nil {
+		t.Fatal(err)
+	}
+	if r.Get("manual") != manual || r.Get("user") != manual || isClosed(manual) {
+		t.Fatal("registered client replaced or closed by reload")
+	}
+}
+
+func TestRegistryConcurrentRegisterAndApply(t *testing.T) {
+	r := NewRegistry()
+	cfg := registryCfg(t, map[string]string{"user": "http://user"})
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 20 {
+				c, err := New(withNopLogger())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				r.Register(fmt.Sprintf("m%d-%d", i, j), c)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if err := r.Apply(cfg); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range 4 {
+		for j := range 20 {
+			if r.Get(fmt.Sprintf("m%d-%d", i, j)) == nil {
+				t.Fatalf("m%d-%d lost", i, j)
+			}
+		}
+	}
+}
+
+// openFilesIn 当前进程在 dir 下打开的文件数，只在有 /proc 的系统上可用
+func openFilesIn(t *testing.T, dir string) int {
+	t.Helper()
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("no /proc/self/fd:", err)
+	}
+	n := 0
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && strings.HasPrefix(target, dir+string(os.PathSeparator)) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRegistrySharesLoggers(t *testing.T) {
+	dir := t.TempDir()
+	logCfg := func(level string) *LogConfig { return &LogConfig{AppName: "app", Dir: dir, Level: level} }
+	cfg := RegistryConfig{Clients: map[string]ClientConfig{
+		"user":  {BaseURL: "http://user", Log: logCfg("info")},
+		"order": {BaseURL: "http://order", Log: logCfg("")},
+	}}
+	r := NewRegistry()
+	if err := r.Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+	user, order := r.Get("user"), r.Get("order")
+	if user.logger != order.logger {
+		t.Fatal("clients writing the same files got separate loggers")
+	}
+	// 只有一个 handler 打开了 info / warn 两个文件
+	if n := openFilesIn(t, dir); n != 2 {
+		t.Fatalf("%d files open in log dir, want 2", n)
+	}
+
+	// 同一组文件配置不一致时拒绝
+	bad := RegistryConfig{Clients: map[string]ClientConfig{
+		"user":  cfg.Clients["user"],
+		"order": {BaseURL: "http://order", Log: logCfg("debug")},
+	}}
+	if err := r.Apply(bad); err == nil {
+		t.Fatal("conflicting log settings applied")
+	}
+	if r.Get("order") != order {
+		t.Fatal("failed reload replaced client")
+	}
+
+	// 仍在使用的文件不能换配置：新旧 handler 会同时切分、清理同一组文件
+	next := RegistryConfig{Clients: map[string]ClientConfig{
+		"user":  {BaseURL: "http://user", Log: logCfg("warn")},
+		"order": {BaseURL: "http://order", Log: logCfg("warn")},
+	}}
+	if err := r.Apply(next); err == nil {
+		t.Fatal("log settings changed for files in use")
+	}
+	if r.Get("user") != user || r.Get("order") != order {
+		t.Fatal("failed reload replaced clients")
+	}
+
+	// 全部下线后，logger 在 client 关闭后关闭
+	if err := r.Apply(RegistryConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	waitClosed(t, user)
+	waitClosed(t, order)
+	deadline := time.Now().Add(5 * time.Second)
+	for openFilesIn(t, dir) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d files open in log dir after removal, want 0", openFilesIn(t, dir))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// 没写 log 的 client 共享 New 的默认 logger
+func TestRegistrySharesDefaultLogger(t *testing.T) {
+	t.Chdir(t.TempDir())
+	r := NewRegistry()
+	err := r.Apply(RegistryConfig{Clients: map[string]ClientConfig{
+		"user":  {BaseURL: "http://user"},
+		"order": {BaseURL: "http://order"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Get("user").logger != r.Get("order").logger {
+		t.Fatal("clients without log section got separate loggers")
+	}
+	dir, _ := filepath.Abs("logs")
+	if n := openFilesIn(t, dir); n != 2 {
+		t.Fatalf("%d files open in default log dir, want 2", n)
+	}
+	_ = r.Apply(RegistryConfig{})
+}
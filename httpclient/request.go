@@ -16,6 +16,12 @@ type Request struct {
 	Body    any         // nil / io.Reader / struct/map(会被 JSON 编码)
 
 	Timeout time.Duration // per-request timeout（优先级高于 Config.DefaultTimeout）
+
+	IdempotencyKey string // 幂等键，所有重试共用
+	Operation      string // 逻辑操作名（如 GraphQL operation），日志 path 和 span 名用它代替 URL path
+
+	MaxResponseBytes int64          // 覆盖 client 的最大响应体，0 使用 client 默认，<0 不限制
+	Decode           *DecodeOptions // 覆盖 client 的解码选项
 }
 
 type RequestOption func(*Request)
@@ -66,7 +72,8 @@ func (c *Client) buildURL(path string, q url.Values) (string, error) {
 	}
 
 	// 没有 BaseURL：直接在相对路径上合并 query
-	if c.baseURL == nil {
+	base := c.pickBaseURL()
+	if base == nil {
 		qs := pu.Query()
 		for k, vs := range q {
 			for _, v := range vs {
@@ -78,8 +85,8 @@ func (c *Client) buildURL(path string, q url.Values) (string, error) {
 	}
 
 	// 基于 BaseURL 拼接
-	u := *c.baseURL
-	u.Path = joinPath(c.baseURL.Path, pu.Path)
+	u := *base
+	u.Path = joinPath(base.Path, pu.Path)
 
 	qs := pu.Query()
 	for k, vs := range q {
@@ -91,6 +98,19 @@ func (c *Client) buildURL(path string, q url.Values) (string, error) {
 	return u.String(), nil
 }
 
+// pickBaseURL 多个 BaseURL 时按调用轮询
+func (c *Client) pickBaseURL() *url.URL {
+	switch len(c.baseURLs) {
+	case 0:
+		return nil
+	case 1:
+		return c.baseURLs[0]
+	default:
+		n := c.nextBase.Add(1) - 1
+		return c.baseURLs[n%uint64(len(c.baseURLs))]
+	}
+}
+
 // joinPath 简单处理一下 / 的拼接
 func joinPath(a, b string) string {
 	switch {
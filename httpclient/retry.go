@@ -8,13 +8,17 @@ import (
 // RetryDecider 决定某次响应是否需要重试
 type RetryDecider func(resp *http.Response, err error) bool
 
+// RetryPolicy 与 RetryDecider 相同，但能看到本次请求（方法、header 等）
+// 设置后优先于 RetryDecider
+type RetryPolicy func(req *http.Request, resp *http.Response, err error) bool
+
 // BackoffFunc 返回第 attempt 次重试前需要 sleep 的时间
 type BackoffFunc func(attempt int) time.Duration
 
-// 默认重试策略：网络错误 + 5xx
+// 默认重试策略：网络错误 + 5xx，被自适应限流拒绝的不重试（避免放大过载）
 func defaultRetryDecider(resp *http.Response, err error) bool {
 	if err != nil {
-		return true
+		return !IsConcurrencyLimited(err)
 	}
 	if resp != nil && resp.StatusCode >= 500 {
 		return true
@@ -24,12 +28,16 @@ func defaultRetryDecider(resp *http.Response, err error) bool {
 
 // 默认指数退避：100ms, 200ms, 400ms, ... 最大 2s
 func defaultBackoff(attempt int) time.Duration {
-	base := 100 * time.Millisecond
-	max := 2 * time.Second
+	return ExponentialBackoff(100*time.Millisecond, 2*time.Second)(attempt)
+}
 
-	d := base << attempt
-	if d > max {
-		d = max
+// ExponentialBackoff 指数退避：base, base*2, base*4, ... 最大 max
+func ExponentialBackoff(base, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		d := base << attempt
+		if d > max || d <= 0 {
+			d = max
+		}
+		return d
 	}
-	return d
 }
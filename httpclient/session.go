@@ -0,0 +1,159 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sync"
+)
+
+// WithCookieJar 开启 client 级 cookie jar，jar 为 nil 时自动创建一个内存 jar；
+// 所有请求共享，需要按登录态隔离时使用 Session
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(c *Config) {
+		c.EnableCookies = true
+		c.CookieJar = jar
+	}
+}
+
+// Session 从 Client 派生的轻量会话：独立的 cookie jar 和默认请求头（如 CSRF token），
+// 共享 client 的连接池、重试、日志等全部配置，并发安全
+type Session struct {
+	c  *Client
+	hc *http.Client
+
+	mu      sync.RWMutex
+	headers http.Header
+}
+
+type SessionOption func(*Session)
+
+// WithSessionJar 使用指定的 cookie jar（例如从持久化恢复的 jar）
+func WithSessionJar(jar http.CookieJar) SessionOption {
+	return func(s *Session) { s.hc.Jar = jar }
+}
+
+// WithSessionHeader 会话默认请求头，请求自己设置的同名 header 优先
+func WithSessionHeader(k, v string) SessionOption {
+	return func(s *Session) { s.headers.Set(k, v) }
+}
+
+// NewSession 创建会话，默认带一个空的内存 cookie jar
+func (c *Client) NewSession(opts ...SessionOption) (*Session, error) {
+	s := &Session{
+		c:       c,
+		hc:      &http.Client{Transport: c.hc.Transport},
+		headers: make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.hc.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		s.hc.Jar = jar
+	}
+	return s, nil
+}
+
+// Jar 会话的 cookie jar
+func (s *Session) Jar() http.CookieJar {
+	return s.hc.Jar
+}
+
+// Cookies 会话中发往 rawURL 的 cookie
+func (s *Session) Cookies(rawURL string) ([]*http.Cookie, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return s.hc.Jar.Cookies(u), nil
+}
+
+// SetHeader 设置会话默认请求头（例如登录后拿到的 CSRF token）
+func (s *Session) SetHeader(k, v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers.Set(k, v)
+}
+
+// DelHeader 删除会话默认请求头
+func (s *Session) DelHeader(k string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers.Del(k)
+}
+
+// Context 把会话绑定到 ctx，之后用这个 ctx 调用 client 的任何方法（Paginate / DoBatch 等）都走该会话
+func (s *Session) Context(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, sessionCtxKey{}, s)
+}
+
+func (s *Session) Do(ctx context.Context, reqCfg *Request, respBody any) (*http.Response, error) {
+	return s.c.Do(s.Context(ctx), reqCfg, respBody)
+}
+
+func (s *Session) GetJSON(ctx context.Context, path string, out any, opts ...RequestOption) (*http.Response, error) {
+	return s.c.GetJSON(s.Context(ctx), path, out, opts...)
+}
+
+func (s *Session) PostJSON(ctx context.Context, path string, in any, out any, opts ...RequestOption) (*http.Response, error) {
+	return s.c.PostJSON(s.Context(ctx), path, in, out, opts...)
+}
+
+func (s *Session) Download(ctx context.Context, reqCfg *Request, dst string, opts ...DownloadOption) (*http.Response, error) {
+	return s.c.Download(s.Context(ctx), reqCfg, dst, opts...)
+}
+
+type sessionCtxKey struct{}
+
+func sessionFrom(ctx context.Context) *Session {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(sessionCtxKey{}).(*Session)
+	return s
+}
+
+// sessionFor ctx 上绑定的、属于 c 的会话；其他 client 的会话不生效，避免把它的凭据发往别的服务
+func (c *Client) sessionFor(ctx context.Context) *Session {
+	if s := sessionFrom(ctx); s != nil && s.c == c {
+		return s
+	}
+	return nil
+}
+
+// mergeHeaders 把会话默认头补到 h 中（h 里已有的不覆盖），s 为 nil 时原样返回
+func (s *Session) mergeHeaders(h http.Header) http.Header {
+	if s == nil {
+		return h
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.headers) == 0 {
+		return h
+	}
+	if h == nil {
+		h = make(http.Header, len(s.headers))
+	}
+	for k, vs := range s.headers {
+		if _, ok := h[k]; !ok {
+			h[k] = append([]string(nil), vs...)
+		}
+	}
+	return h
+}
+
+// httpClient 请求所属会话的 http.Client，没有会话时用 client 自己的
+func (c *Client) httpClient(ctx context.Context) *http.Client {
+	if s := c.sessionFor(ctx); s != nil {
+		return s.hc
+	}
+	return c.hc
+}
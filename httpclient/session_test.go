@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionCookieIsolation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: r.URL.Query().Get("user")})
+			return
+		}
+		ck, err := r.Cookie("sid")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`"` + ck.Value + "/" + r.Header.Get("X-Csrf-Token") + `"`))
+	}))
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL), WithCurlOnFailure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	login := func(user string) *Session {
+		s, err := c.NewSession(WithSessionHeader("X-Csrf-Token", "t-"+user))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Do(ctx, &Request{Method: http.MethodGet, Path: "/login?user=" + user}, nil); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	alice, bob := login("alice"), login("bob")
+
+	for s, want := range map[*Session]string{alice: "alice/t-alice", bob: "bob/t-bob"} {
+		var got string
+		if _, err := s.GetJSON(ctx, "/me", &got); err != nil || got != want {
+			t.Fatalf("got %q err=%v, want %q", got, err, want)
+		}
+	}
+
+	// client 本身没有 jar，不带会话的调用拿不到 cookie
+	var got string
+	if _, err := c.GetJSON(ctx, "/me", &got); err == nil {
+		t.Fatal("want 401 without session")
+	}
+}
+
+func TestSessionIgnoredByOtherClient(t *testing.T) {
+	var gotAuth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	a, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := a.NewSession(WithSessionHeader("Authorization", "Bearer a-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := s.Context(context.Background())
+
+	if _, err := a.GetJSON(ctx, "/", nil); err != nil {
+		t.Fatal(err)
+	}
+	// 同一个 ctx 交给另一个 client，不能带上 a 的会话头
+	if _, err := b.GetJSON(ctx, "/", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(gotAuth) != 2 || gotAuth[0] != "Bearer a-secret" || gotAuth[1] != "" {
+		t.Fatalf("Authorization = %q", gotAuth)
+	}
+}
+
+func TestRedactCookies(t *testing.T) {
+	r := newRedactor(nil)
+	if got := r.value("cookie", "sid=abc; csrf=def"); got != "sid=***; csrf=***" {
+		t.Fatalf("cookie: %q", got)
+	}
+	if got := r.value("Set-Cookie", "sid=abc; Path=/; HttpOnly"); got != "sid=***" {
+		t.Fatalf("set-cookie: %q", got)
+	}
+}
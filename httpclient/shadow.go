@@ -0,0 +1,277 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/imattdu/orbit/logx"
+	"github.com/imattdu/orbit/tracex"
+)
+
+// 对比结果里 body 最多保留的字节数
+const shadowMaxBody = 1024
+
+// ShadowConfig 影子流量配置：按比例把请求异步重放到 BaseURL，对比结果，不影响主请求
+type ShadowConfig struct {
+	BaseURL string
+	Percent float64       // 0~100
+	Timeout time.Duration // 影子请求超时，默认 3s
+
+	// 默认只重放幂等方法，开启后 POST / PATCH 也会重放（注意影子环境的写副作用）
+	IncludeNonIdempotent bool
+	// 同时在途的影子请求上限，超过直接丢弃，默认 64
+	MaxInflight int
+	// 请求体超过该大小时不重放；影子响应体最多读这么多，超出时不比较 body。默认 1MB
+	MaxBodyBytes int64
+	// 默认不转发脱敏头（Authorization / Cookie 等，含 WithRedactHeaders 追加的）和幂等键，
+	// 确实需要转发给影子环境的头列在这里
+	ForwardHeaders []string
+
+	Hook ShadowHook
+}
+
+// ShadowResult 一次影子请求的对比结果
+type ShadowResult struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	TraceID string `json:"trace_id"`
+
+	PrimaryStatus int           `json:"primary_status"`
+	ShadowStatus  int           `json:"shadow_status"`
+	ShadowErr     error         `json:"-"`
+	ShadowCost    time.Duration `json:"shadow_cost"`
+
+	StatusDiff  bool   `json:"status_diff"`
+	BodyDiff    bool   `json:"body_diff"`
+	BodySkipped bool   `json:"body_skipped,omitempty"` // 响应体超过 MaxBodyBytes，未比较
+	PrimaryBody string `json:"primary_body,omitempty"` // 有差异时才填，截断到 1KB
+	ShadowBody  string `json:"shadow_body,omitempty"`
+}
+
+// ShadowHook 对比结果回调（例如打点）
+type ShadowHook func(ctx context.Context, r *ShadowResult)
+
+// WithShadow 开启影子流量
+func WithShadow(cfg ShadowConfig) Option {
+	return func(c *Config) { c.Shadow = &cfg }
+}
+
+type shadower struct {
+	cfg     ShadowConfig
+	base    *url.URL
+	sem     chan struct{}
+	forward map[string]bool // ForwardHeaders，已规范化
+	hc      *http.Client    // 与主 client 共享连接池，但不带 cookie jar，会话 cookie 不发往影子环境
+}
+
+func newShadower(cfg *ShadowConfig) (*shadower, error) {
+	if cfg == nil || cfg.BaseURL == "" || cfg.Percent <= 0 {
+		return nil, nil
+	}
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	s := &shadower{cfg: *cfg, base: base}
+	if s.cfg.Timeout <= 0 {
+		s.cfg.Timeout = 3 * time.Second
+	}
+	if s.cfg.MaxInflight <= 0 {
+		s.cfg.MaxInflight = 64
+	}
+	if s.cfg.MaxBodyBytes <= 0 {
+		s.cfg.MaxBodyBytes = 1 << 20
+	}
+	s.sem = make(chan struct{}, s.cfg.MaxInflight)
+	s.forward = make(map[string]bool, len(cfg.ForwardHeaders))
+	for _, h := range cfg.ForwardHeaders {
+		s.forward[http.CanonicalHeaderKey(h)] = true
+	}
+	return s, nil
+}
+
+// shadowCall 主请求需要的信息快照
+type shadowCall struct {
+	method  string
+	url     string // 主请求完整 URL
+	headers http.Header
+	body    []byte
+}
+
+// mirror 按比例异步重放，主请求的 status / body 作为对比基准
+func (c *Client) mirror(ctx context.Context, call shadowCall, primaryStatus int, primaryBody []byte) {
+	s := c.shadow
+	if s == nil || (!s.cfg.IncludeNonIdempotent && !isIdempotentMethod(call.method)) {
+		return
+	}
+	if int64(len(call.body)) > s.cfg.MaxBodyBytes {
+		return
+	}
+	if s.cfg.Percent < 100 && rand.Float64()*100 >= s.cfg.Percent {
+		return
+	}
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		// 影子请求积压，丢弃，保护主链路
+		return
+	}
+	// 影子请求也算在途调用，Close 时会等待
+	if c.acquire() != nil {
+		<-s.sem
+		return
+	}
+
+	// 脱离调用方的取消，但保留 trace 等 ctx 信息
+	ctx = context.WithoutCancel(ctx)
+	primaryBody = cloneBytes(primaryBody)
+	go func() {
+		defer func() {
+			<-s.sem
+			c.release()
+		}()
+		r := c.replay(ctx, call)
+		r.PrimaryStatus = primaryStatus
+		r.StatusDiff = r.ShadowErr != nil || r.ShadowStatus != primaryStatus
+		// 主请求没有读 body（respBody 为 nil / io.Writer）时只比较状态码
+		if int64(len(primaryBody)) > s.cfg.MaxBodyBytes {
+			r.BodySkipped = true
+		}
+		r.BodyDiff = r.ShadowErr == nil && !r.BodySkipped && primaryBody != nil && !sameBody(primaryBody, []byte(r.ShadowBody))
+		if r.BodyDiff {
+			r.PrimaryBody = truncate(primaryBody, shadowMaxBody)
+			r.ShadowBody = truncate([]byte(r.ShadowBody), shadowMaxBody)
+		} else {
+			r.ShadowBody = ""
+		}
+		c.reportShadow(ctx, r)
+	}()
+}
+
+// replay 发出影子请求，ShadowBody 暂存完整响应体供对比
+func (c *Client) replay(ctx context.Context, call shadowCall) *ShadowResult {
+	s := c.shadow
+	r := &ShadowResult{Method: call.method, TraceID: tracex.TraceIDFromContext(ctx)}
+
+	u, err := url.Parse(call.url)
+	if err != nil {
+		r.ShadowErr = err
+		return r
+	}
+	r.Path = u.Path
+	u.Scheme, u.Host = s.base.Scheme, s.base.Host
+	u.Path = joinPath(s.base.Path, u.Path)
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+	var body io.Reader
+	if call.body != nil {
+		body = bytes.NewReader(call.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, call.method, u.String(), body)
+	if err != nil {
+		r.ShadowErr = err
+		return r
+	}
+	req.Header = cloneHeader(call.headers)
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	tracex.InjectToHeader(ctx, req.Header)
+	for _, h := range c.before {
+		h(ctx, req)
+	}
+	// before hook 可能补上鉴权头，最后再统一去掉
+	req.Header = c.shadowHeaders(req.Header)
+
+	start := time.Now()
+	resp, err := s.hc.Do(req)
+	if err != nil {
+		r.ShadowErr = err
+		r.ShadowCost = time.Since(start)
+		return r
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, s.cfg.MaxBodyBytes+1))
+	r.ShadowCost = time.Since(start)
+	r.ShadowStatus = resp.StatusCode
+	r.ShadowErr = err
+	if int64(len(data)) > s.cfg.MaxBodyBytes {
+		r.BodySkipped = true
+		data = nil
+	}
+	r.ShadowBody = string(data)
+	return r
+}
+
+// shadowHeaders 复制主请求的头，去掉脱敏头和幂等键（ForwardHeaders 中的除外）：
+// 凭证不外泄到影子环境，幂等键也不会让影子环境把重放当成重复请求
+func (c *Client) shadowHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		k = http.CanonicalHeaderKey(k)
+		if !c.shadow.forward[k] {
+			if _, sensitive := c.redact[k]; sensitive ||
+				k == HeaderIdempotencyKey || k == http.CanonicalHeaderKey(c.idempotencyHeaderName()) {
+				continue
+			}
+		}
+		out[k] = append([]string(nil), vs...)
+	}
+	return out
+}
+
+func (c *Client) reportShadow(ctx context.Context, r *ShadowResult) {
+	logMap := map[string]interface{}{
+		logx.Method:      r.Method,
+		logx.Path:        r.Path,
+		"primary_status": r.PrimaryStatus,
+		"shadow_status":  r.ShadowStatus,
+		"status_diff":    r.StatusDiff,
+		"body_diff":      r.BodyDiff,
+		logx.Cost:        r.ShadowCost / time.Millisecond,
+	}
+	if r.ShadowErr != nil {
+		logMap[logx.Err] = r.ShadowErr.Error()
+	}
+	if r.BodyDiff {
+		logMap["primary_body"] = r.PrimaryBody
+		logMap["shadow_body"] = r.ShadowBody
+	}
+	if r.BodySkipped {
+		logMap["body_skipped"] = true
+	}
+	if r.StatusDiff || r.BodyDiff {
+		c.logger.Warn(ctx, logx.TagHttpShadow, logMap)
+	} else {
+		c.logger.Info(ctx, logx.TagHttpShadow, logMap)
+	}
+	if h := c.shadow.cfg.Hook; h != nil {
+		h(ctx, r)
+	}
+}
+
+// sameBody 两边都是 JSON 时按语义比较（忽略字段顺序 / 空白），否则按字节比较
+func sameBody(a, b []byte) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) == nil && json.Unmarshal(b, &vb) == nil {
+		return reflect.DeepEqual(va, vb)
+	}
+	return bytes.Equal(a, b)
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) > n {
+		return string(b[:n])
+	}
+	return string(b)
+}
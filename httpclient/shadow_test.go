@@ -0,0 +1,214 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func jsonServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func waitShadow(t *testing.T, ch <-chan *ShadowResult) *ShadowResult {
+	t.Helper()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow request not reported")
+		return nil
+	}
+}
+
+func TestShadowMirrorsWithoutSecrets(t *testing.T) {
+	primary := jsonServer(`{"id":1}`)
+	defer primary.Close()
+	got := make(chan http.Header, 1)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Clone()
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer shadow.Close()
+
+	results := make(chan *ShadowResult, 1)
+	c, err := New(withNopLogger(), WithBaseURL(primary.URL), WithIdempotencyHeader(""),
+		WithRedactHeaders("X-Tenant-Secret"),
+		WithShadow(ShadowConfig{
+			BaseURL: shadow.URL, Percent: 100, IncludeNonIdempotent: true,
+			ForwardHeaders: []string{"X-Api-Key"},
+			Hook:           func(_ context.Context, r *ShadowResult) { results <- r },
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	_, err = c.PostJSON(context.Background(), "/users", map[string]int{"id": 1}, &out,
+		WithHeader("Authorization", "Bearer secret"),
+		WithHeader("Cookie", "sid=secret"),
+		WithHeader("X-Tenant-Secret", "secret"),
+		WithHeader("X-Api-Key", "forwarded"),
+		WithHeader("X-Request-From", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := <-got
+	for _, k := range []string{"Authorization", "Cookie", "X-Tenant-Secret", HeaderIdempotencyKey} {
+		if v := h.Get(k); v != "" {
+			t.Errorf("%s forwarded to shadow: %q", k, v)
+		}
+	}
+	if h.Get("X-Api-Key") != "forwarded" || h.Get("X-Request-From") != "test" {
+		t.Fatalf("headers missing on shadow: %v", h)
+	}
+	r := waitShadow(t, results)
+	if r.StatusDiff || r.BodyDiff || r.ShadowStatus != http.StatusOK {
+		t.Fatalf("unexpected diff: %+v", r)
+	}
+}
+
+// before hook 补上的鉴权头、client 的 cookie jar 都不能带到影子环境
+func TestShadowStripsHookHeadersAndCookies(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "secret", Path: "/"})
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer primary.Close()
+	got := make(chan http.Header, 2)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.Header.Clone()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer shadow.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(primary.URL), WithCookieJar(nil),
+		WithBeforeHooks(func(_ context.Context, req *http.Request) {
+			req.Header.Set("Authorization", "Bearer from-hook")
+		}),
+		WithShadow(ShadowConfig{BaseURL: shadow.URL, Percent: 100}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一次拿到 cookie（primary 与 shadow 同为 127.0.0.1，jar 不区分端口）
+	for range 2 {
+		if _, err := c.GetJSON(context.Background(), "/", nil); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case h := <-got:
+			if v := h.Get("Authorization"); v != "" {
+				t.Fatalf("hook Authorization forwarded to shadow: %q", v)
+			}
+			if v := h.Get("Cookie"); v != "" {
+				t.Fatalf("cookie jar forwarded to shadow: %q", v)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("shadow request not sent")
+		}
+	}
+}
+
+func TestShadowIsAsyncAndIsolated(t *testing.T) {
+	primary := jsonServer(`{"id":1}`)
+	defer primary.Close()
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	results := make(chan *ShadowResult, 1)
+	c, err := New(withNopLogger(), WithBaseURL(primary.URL), WithShadow(ShadowConfig{
+		BaseURL: slow.URL, Percent: 100,
+		Hook: func(_ context.Context, r *ShadowResult) { results <- r },
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 影子请求卡住时，主请求照常返回
+	start := time.Now()
+	var out map[string]any
+	if _, err := c.GetJSON(context.Background(), "/users/1", &out); err != nil || out["id"] != float64(1) {
+		t.Fatalf("primary: %v %v", out, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("primary waited for shadow")
+	}
+	select {
+	case <-results:
+		t.Fatal("shadow finished before it was released")
+	default:
+	}
+
+	// 影子失败（5xx）只体现在对比结果里
+	release <- struct{}{}
+	if r := waitShadow(t, results); !r.StatusDiff || r.ShadowStatus != http.StatusInternalServerError {
+		t.Fatalf("shadow result: %+v", r)
+	}
+
+	// 影子地址不可达
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	c2, err := New(withNopLogger(), WithBaseURL(primary.URL), WithShadow(ShadowConfig{
+		BaseURL: dead.URL, Percent: 100,
+		Hook: func(_ context.Context, r *ShadowResult) { results <- r },
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c2.GetJSON(context.Background(), "/users/1", &out); err != nil {
+		t.Fatalf("primary failed with unreachable shadow: %v", err)
+	}
+	if r := waitShadow(t, results); r.ShadowErr == nil {
+		t.Fatalf("shadow error not reported: %+v", r)
+	}
+}
+
+func TestShadowBodyLimit(t *testing.T) {
+	primary := jsonServer(`"` + strings.Repeat("p", 100) + `"`)
+	defer primary.Close()
+	hits := make(chan struct{}, 4)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits <- struct{}{}
+		_, _ = w.Write([]byte(`"` + strings.Repeat("s", 100) + `"`))
+	}))
+	defer shadow.Close()
+
+	results := make(chan *ShadowResult, 1)
+	c, err := New(withNopLogger(), WithBaseURL(primary.URL), WithShadow(ShadowConfig{
+		BaseURL: shadow.URL, Percent: 100, IncludeNonIdempotent: true, MaxBodyBytes: 50,
+		Hook: func(_ context.Context, r *ShadowResult) { results <- r },
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	var out string
+
+	// 请求体超过上限：不重放
+	if _, err := c.PostJSON(ctx, "/", strings.Repeat("x", 100), &out); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-hits:
+		t.Fatal("oversized request mirrored")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 响应体超过上限：只比较状态码
+	if _, err := c.GetJSON(ctx, "/", &out); err != nil {
+		t.Fatal(err)
+	}
+	if r := waitShadow(t, results); !r.BodySkipped || r.BodyDiff || r.StatusDiff {
+		t.Fatalf("shadow result: %+v", r)
+	}
+}
@@ -4,18 +4,39 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// DialFunc 与 net.Dialer.DialContext 签名一致
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithDialer 自定义拨号（例如走 sidecar / 自定义网络栈），外层仍会套上读写超时和 DNS 缓存
+func WithDialer(dial DialFunc) Option {
+	return func(c *Config) { c.DialContext = dial }
+}
+
+// WithUnixSocket 所有连接都走 unix domain socket（URL 的 host 只作为 Host 头）；
+// 只有部分 base URL 走 socket 时用 "unix:///path/to.sock" 形式的 BaseURL
+func WithUnixSocket(path string) Option {
+	return func(c *Config) { c.UnixSocket = path }
+}
+
+// WithH2C 明文 HTTP/2（prior knowledge），用于内部 h2c 服务；https 仍走 HTTP/2
+func WithH2C() Option {
+	return func(c *Config) { c.H2C = true }
+}
+
+// WithHappyEyeballs 双栈时主地址族拨号 delay 后仍未成功就并行尝试另一个地址族（RFC 6555），<0 关闭
+func WithHappyEyeballs(delay time.Duration) Option {
+	return func(c *Config) { c.FallbackDelay = delay }
+}
+
 // 构造 http.Transport
-func buildTransport(cfg *Config) *http.Transport {
-	return &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: makeDialContext(
-			cfg.DialTimeout,
-			cfg.DialKeepAlive,
-			cfg.ReadWriteTimeout,
-		),
+func buildTransport(cfg *Config, sockets map[string]string, proxy proxyFunc) *http.Transport {
+	tr := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           makeDialContext(cfg, sockets),
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          cfg.MaxIdleConns,
 		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
@@ -23,6 +44,13 @@ func buildTransport(cfg *Config) *http.Transport {
 		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
 		ExpectContinueTimeout: cfg.ExpectContinueTimeout,
 	}
+	if cfg.H2C {
+		var p http.Protocols
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+		tr.Protocols = &p
+	}
+	return tr
 }
 
 // timeoutConn 在每次 Read/Write 前设置 deadline，控制每次读写超时
@@ -45,15 +73,48 @@ func (c *timeoutConn) Write(b []byte) (int, error) {
 	return c.Conn.Write(b)
 }
 
-// 包装 DialContext，增加读写超时
-func makeDialContext(dial, keepAlive, rw time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
-	d := &net.Dialer{Timeout: dial, KeepAlive: keepAlive}
-	if rw <= 0 {
-		return d.DialContext
+// unixHost "/var/run/app.sock" → "var-run-app.sock"，作为 unix:// base URL 的占位 host（日志里可读）
+func unixHost(path string) string {
+	h := strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '-'
+	}, path)
+	return strings.Trim(h, "-.")
+}
+
+// makeDialContext 组装拨号链：unix socket → DNS 缓存 + happy eyeballs → 自定义 / 默认 dialer，
+// 最外层包装读写超时
+func makeDialContext(cfg *Config, sockets map[string]string) DialFunc {
+	d := &net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: cfg.DialKeepAlive, FallbackDelay: cfg.FallbackDelay}
+	dial := d.DialContext
+	if cfg.DialContext != nil {
+		dial = cfg.DialContext
+	}
+	if cfg.DNSCache != nil {
+		dial = newDNSCache(*cfg.DNSCache).dialer(dial, cfg.FallbackDelay)
+	}
+	if cfg.UnixSocket != "" || len(sockets) > 0 {
+		next := dial
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			path := cfg.UnixSocket
+			if host, _, err := net.SplitHostPort(addr); err == nil && sockets[host] != "" {
+				path = sockets[host]
+			}
+			if path == "" {
+				return next(ctx, network, addr)
+			}
+			return d.DialContext(ctx, "unix", path)
+		}
 	}
 
+	rw := cfg.ReadWriteTimeout
+	if rw <= 0 {
+		return dial
+	}
 	return func(ctx context.Context, network, addr string) (net.Conn, error) {
-		conn, err := d.DialContext(ctx, network, addr)
+		conn, err := dial(ctx, network, addr)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,270 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestUnixSocketH2C(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skip("unix socket not supported:", err)
+	}
+	var p http.Protocols
+	p.SetHTTP1(true)
+	p.SetUnencryptedHTTP2(true)
+	srv := &http.Server{
+		Protocols: &p,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`"` + r.Proto + `"`))
+		}),
+	}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+
+	for _, tc := range []struct {
+		opts  []Option
+		proto string
+	}{
+		{[]Option{WithBaseURL("unix://" + sock)}, "HTTP/1.1"},
+		{[]Option{WithBaseURL("unix://" + sock), WithH2C()}, "HTTP/2.0"},
+	} {
+		c, err := New(append(tc.opts, withNopLogger())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var proto string
+		if _, err := c.GetJSON(context.Background(), "/ping", &proto); err != nil {
+			t.Fatal(err)
+		}
+		if proto != tc.proto {
+			t.Fatalf("proto = %s, want %s", proto, tc.proto)
+		}
+	}
+}
+
+type countingResolver struct {
+	calls atomic.Int32
+	addrs []string
+}
+
+func (r *countingResolver) LookupHost(context.Context, string) ([]string, time.Duration, error) {
+	r.calls.Add(1)
+	return r.addrs, time.Hour, nil
+}
+
+func TestDNSCacheHappyEyeballs(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`"ok"`))
+	})}
+	go func() { _ = srv.Serve(ln) }()
+	defer func() { _ = srv.Close() }()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	// 主地址族（IPv6 的文档地址）不可达，回退到 IPv4
+	r := &countingResolver{addrs: []string{"2001:db8::1", "127.0.0.1"}}
+	c, err := New(withNopLogger(), WithBaseURL("http://svc.internal:"+port),
+		WithDNSCache(DNSCacheConfig{Resolver: r}), WithHappyEyeballs(20*time.Millisecond),
+		func(c *Config) { c.MaxIdleConnsPerHost = -1 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		var got string
+		if _, err := c.GetJSON(context.Background(), "/", &got); err != nil || got != "ok" {
+			t.Fatalf("got %q err=%v", got, err)
+		}
+	}
+	if n := r.calls.Load(); n != 1 {
+		t.Fatalf("resolver called %d times, want 1", n)
+	}
+}
+
+// fakeDNSServer 在同一端口上提供 UDP / TCP DNS：records 为域名 → A 记录数，
+// 超过 10 条的答案在 UDP 上只回 TC 位，需要 TCP 重查
+func fakeDNSServer(t *testing.T, records map[string]int) string {
+	t.Helper()
+	var (
+		pc  net.PacketConn
+		ln  net.Listener
+		err error
+	)
+	for i := 0; i < 10; i++ {
+		if pc, err = net.ListenPacket("udp4", "127.0.0.1:0"); err != nil {
+			t.Fatal(err)
+		}
+		if ln, err = net.Listen("tcp4", pc.LocalAddr().String()); err == nil {
+			break
+		}
+		_ = pc.Close()
+	}
+	if err != nil {
+		t.Skip("no free udp+tcp port:", err)
+	}
+	t.Cleanup(func() { _ = pc.Close(); _ = ln.Close() })
+
+	answer := func(req []byte, udp bool) []byte {
+		var p dnsmessage.Parser
+		h, err := p.Start(req)
+		if err != nil {
+			return nil
+		}
+		q, err := p.Question()
+		if err != nil {
+			return nil
+		}
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true},
+			Questions: []dnsmessage.Question{q},
+		}
+		n, ok := records[q.Name.String()]
+		switch {
+		case !ok:
+			resp.RCode = dnsmessage.RCodeNameError
+		case udp && n > 10:
+			resp.Truncated = true
+		case q.Type == dnsmessage.TypeA:
+			for i := 0; i < n; i++ {
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: [4]byte{10, 0, byte(i >> 8), byte(i)}},
+				})
+			}
+		}
+		out, _ := resp.Pack()
+		return out
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(answer(buf[:n], true), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				var l [2]byte
+				if _, err := io.ReadFull(conn, l[:]); err != nil {
+					return
+				}
+				req := make([]byte, binary.BigEndian.Uint16(l[:]))
+				if _, err := io.ReadFull(conn, req); err != nil {
+					return
+				}
+				out := answer(req, false)
+				binary.BigEndian.PutUint16(l[:], uint16(len(out)))
+				_, _ = conn.Write(append(l[:], out...))
+			}()
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestDNSResolverSearchList(t *testing.T) {
+	server := fakeDNSServer(t, map[string]int{"svc.ns.svc.cluster.local.": 1, "api.example.com.": 1})
+	conf := parseResolvConf(strings.NewReader("nameserver 10.0.0.10\nnameserver 10.0.0.11\n" +
+		"search ns.svc.cluster.local svc.cluster.local cluster.local\noptions ndots:5 timeout:1\n"))
+	if conf.nameserver != "10.0.0.10:53" || conf.ndots != 5 || len(conf.search) != 3 {
+		t.Fatalf("resolv.conf = %+v", conf)
+	}
+	r := &dnsResolver{server: server, search: conf.search, ndots: conf.ndots}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, host := range []string{"svc", "svc.ns", "svc.ns.svc.cluster.local.", "api.example.com"} {
+		addrs, ttl, err := r.LookupHost(ctx, host)
+		if err != nil || len(addrs) != 1 || ttl != time.Minute {
+			t.Fatalf("%s: addrs=%v ttl=%v err=%v", host, addrs, ttl, err)
+		}
+	}
+	_, _, err := r.LookupHost(ctx, "missing")
+	var de *net.DNSError
+	if !errors.As(err, &de) || !de.IsNotFound || de.Name != "missing" {
+		t.Fatalf("missing: %v", err)
+	}
+}
+
+func TestDNSResolverTCPFallback(t *testing.T) {
+	server := fakeDNSServer(t, map[string]int{"big.example.": 100})
+	r := &dnsResolver{server: server, ndots: 1}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, _, err := r.LookupHost(ctx, "big.example")
+	if err != nil || len(addrs) != 100 {
+		t.Fatalf("got %d addrs err=%v", len(addrs), err)
+	}
+}
+
+// ctx 没有 deadline 时，服务器不响应也不能一直等下去
+func TestDNSResolverTimeout(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	r := &dnsResolver{server: pc.LocalAddr().String(), ndots: 1, timeout: 50 * time.Millisecond}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := r.LookupHost(context.Background(), "silent.example.")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		var ne net.Error
+		if !errors.As(err, &ne) || !ne.Timeout() {
+			t.Fatalf("want timeout, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("lookup blocked without a ctx deadline")
+	}
+}
+
+// 过期（且超过 StaleTTL）的记录会被清理，不会一直占着缓存
+func TestDNSCacheEvictsExpired(t *testing.T) {
+	r := &countingResolver{addrs: []string{"127.0.0.1"}}
+	c := newDNSCache(DNSCacheConfig{Resolver: r, DefaultTTL: 10 * time.Millisecond, MaxTTL: 10 * time.Millisecond})
+	ctx := context.Background()
+	if _, err := c.lookup(ctx, "a.internal"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.lookup(ctx, "b.internal"); err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	_, stale := c.entries["a.internal"]
+	c.mu.Unlock()
+	if stale {
+		t.Fatal("expired entry not swept")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.lookup(ctx, "b.internal"); err != nil || r.calls.Load() != 3 {
+		t.Fatalf("err=%v calls=%d, want 3", err, r.calls.Load())
+	}
+}
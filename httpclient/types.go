@@ -13,6 +13,10 @@ type CallAttempt struct {
 	Err       error         `json:"err,omitempty"`
 	Cost      time.Duration `json:"cost"`
 	WillRetry bool          `json:"will_retry"`
+	Fault     string        `json:"fault,omitempty"` // 命中的故障注入规则
+	Limit     int           `json:"limit,omitempty"` // 自适应并发限制的当前上限
+	Curl      string        `json:"curl,omitempty"`  // 失败时等价的 curl 命令（已脱敏）
+	Proxy     string        `json:"proxy,omitempty"` // 使用的代理（不含账号密码），直连为空
 	ctx       context.Context
 }
 
@@ -21,6 +25,7 @@ type CallStats struct {
 	ctx context.Context
 	// 请求级
 	Method string `json:"method"`
+	Host   string `json:"host"`
 	URL    string `json:"url"`
 	Path   string `json:"path"`
 	Query  string `json:"query"`
@@ -29,6 +34,9 @@ type CallStats struct {
 	Body     string `json:"body,omitempty"`
 	BodySize int    `json:"body_size,omitempty"`
 
+	// 幂等键（开启时所有重试共用）
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
 	// 重试情况
 	MaxAttempts int           `json:"max_attempts"`
 	Attempts    int           `json:"attempts"`
@@ -0,0 +1,590 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/imattdu/orbit/errorx"
+	"github.com/imattdu/orbit/logx"
+	"github.com/imattdu/orbit/tracex"
+)
+
+// WSMessageType 消息类型
+type WSMessageType int
+
+const (
+	WSText   WSMessageType = 1
+	WSBinary WSMessageType = 2
+)
+
+const (
+	wsOpContinuation = 0x0
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsGUID                  = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	defaultWSMaxMessageSize = 16 << 20
+	defaultWSHandshake      = 10 * time.Second
+)
+
+type wsConfig struct {
+	subprotocols  []string
+	pingInterval  time.Duration
+	maxMessage    int64
+	handshake     time.Duration
+	maxReconnects int // <0 不限次数，0 不重连
+	backoff       BackoffFunc
+	onConnect     func(ctx context.Context, ws *WSConn) error
+}
+
+type WSOption func(*wsConfig)
+
+// WithWSSubprotocols Sec-WebSocket-Protocol
+func WithWSSubprotocols(p ...string) WSOption {
+	return func(c *wsConfig) { c.subprotocols = p }
+}
+
+// WithWSPing 每隔 interval 发一次 ping，2 个 interval 内没收到任何帧视为连接已断，<=0 关闭；
+// pong 在 ReadMessage 中处理，开启时需要有 goroutine 持续读
+func WithWSPing(interval time.Duration) WSOption {
+	return func(c *wsConfig) { c.pingInterval = interval }
+}
+
+// WithWSMaxMessageSize 单条消息上限，默认 16MB
+func WithWSMaxMessageSize(n int64) WSOption {
+	return func(c *wsConfig) { c.maxMessage = n }
+}
+
+// WithWSHandshakeTimeout 每次拨号 + 握手的超时，默认 10s，<=0 不限制（只受 ctx 控制）
+func WithWSHandshakeTimeout(d time.Duration) WSOption {
+	return func(c *wsConfig) { c.handshake = d }
+}
+
+// WithWSReconnect 读写出错时自动重连，max<0 不限次数，backoff 为 nil 时使用 client 的重试退避
+func WithWSReconnect(max int, backoff BackoffFunc) WSOption {
+	return func(c *wsConfig) {
+		c.maxReconnects = max
+		c.backoff = backoff
+	}
+}
+
+// WithWSOnConnect 每次（重）连接成功后回调，例如重新订阅；返回错误视为本次连接失败
+func WithWSOnConnect(fn func(ctx context.Context, ws *WSConn) error) WSOption {
+	return func(c *wsConfig) { c.onConnect = fn }
+}
+
+// WSConn WebSocket 连接：
+//   - 握手复用 client 的 BaseURL、Before hook（鉴权）、TLS / 代理 / 拨号配置和 trace header
+//   - 同一时间只能有一个 goroutine 读，写是并发安全的
+//   - 开启重连时，断线期间正在写的消息会在重连后重发一次，服务端推送的消息可能丢失
+type WSConn struct {
+	c      *Client
+	reqCfg *Request
+	cfg    wsConfig
+	ctx    context.Context // 握手 / 日志用，不随 Dial 的 ctx 取消
+
+	mu          sync.Mutex // 保护 cur / subprotocol
+	cur         *wsConn
+	subprotocol string
+	reconnectMu sync.Mutex // 同一时间只有一个 goroutine 在重连
+
+	closed      atomic.Bool
+	inOnConnect atomic.Bool
+	stop        chan struct{}
+
+	// 读方独占：ReadMessage 的 ctx 取消后，进行中的读留给下一次 ReadMessage 接着等
+	pending     chan wsReadResult
+	pendingConn *wsConn
+}
+
+type wsReadResult struct {
+	typ  WSMessageType
+	data []byte
+	err  error
+}
+
+// wsConn 一条底层连接
+type wsConn struct {
+	rwc      io.ReadWriteCloser
+	br       *bufio.Reader
+	wmu      sync.Mutex
+	lastSeen atomic.Int64 // 最近一次收到帧的时间
+	once     sync.Once
+}
+
+func (c *wsConn) close() {
+	c.once.Do(func() { _ = c.rwc.Close() })
+}
+
+// DialWebSocket 建立 WebSocket 连接，reqCfg.Path 可以是相对路径，也可以是 ws:// / wss:// 完整地址
+func (c *Client) DialWebSocket(ctx context.Context, reqCfg *Request, opts ...WSOption) (*WSConn, error) {
+	cfg := wsConfig{pingInterval: 30 * time.Second, maxMessage: defaultWSMaxMessageSize, handshake: defaultWSHandshake}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.backoff == nil {
+		cfg.backoff = c.backoff
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// 只有握手计入在途调用，长连接不阻塞 Client.Close
+	if err := c.acquire(); err != nil {
+		return nil, err
+	}
+	defer c.release()
+	ws := &WSConn{c: c, reqCfg: reqCfg, cfg: cfg, ctx: context.WithoutCancel(ctx), stop: make(chan struct{})}
+	if err := ws.connect(ctx); err != nil {
+		return nil, err
+	}
+	if cfg.pingInterval > 0 {
+		go ws.keepalive()
+	}
+	return ws, nil
+}
+
+// Subprotocol 服务端选择的子协议
+func (ws *WSConn) Subprotocol() string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.subprotocol
+}
+
+// connect 握手一次，成功后替换当前连接
+func (ws *WSConn) connect(ctx context.Context) (err error) {
+	c := ws.c
+	start := time.Now()
+	ctx, _ = tracex.StartSpan(ctx, "websocket")
+	var target string
+	defer func() {
+		tracex.EndSpan(ctx, err)
+		logMap := map[string]interface{}{
+			"event":   "connect",
+			logx.URL:  target,
+			logx.Cost: time.Since(start) / time.Millisecond,
+		}
+		if err != nil {
+			logMap[logx.Err] = err.Error()
+			c.logger.Warn(ctx, logx.TagWebsocket, logMap)
+			return
+		}
+		logMap["subprotocol"] = ws.Subprotocol()
+		c.logger.Info(ctx, logx.TagWebsocket, logMap)
+	}()
+
+	path := ws.reqCfg.Path
+	switch {
+	case strings.HasPrefix(path, "ws://"):
+		path = "http://" + strings.TrimPrefix(path, "ws://")
+	case strings.HasPrefix(path, "wss://"):
+		path = "https://" + strings.TrimPrefix(path, "wss://")
+	}
+	u, err := c.buildURL(path, ws.reqCfg.Query)
+	if err != nil {
+		return err
+	}
+	target = u
+
+	var keyRaw [16]byte
+	if _, err := rand.Read(keyRaw[:]); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(keyRaw[:])
+
+	// 对端接受了 TCP 却迟迟不完成升级时，不能让重连一直挂着
+	hctx := ctx
+	if ws.cfg.handshake > 0 {
+		var cancel context.CancelFunc
+		hctx, cancel = context.WithTimeout(ctx, ws.cfg.handshake)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(hctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	for k, vs := range ws.c.sessionFor(ctx).mergeHeaders(cloneHeader(ws.reqCfg.Headers)) {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if len(ws.cfg.subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(ws.cfg.subprotocols, ", "))
+	}
+	tracex.InjectToHeader(ctx, req.Header)
+	for _, h := range c.before {
+		h(ctx, req)
+	}
+
+	hc := &http.Client{Transport: c.wsTransport, Jar: c.httpClient(ctx).Jar}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if resp.StatusCode != http.StatusSwitchingProtocols || !ok {
+		_ = resp.Body.Close()
+		return c.wsHandshakeError(resp.StatusCode, "unexpected status "+resp.Status)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		_ = rwc.Close()
+		return c.wsHandshakeError(resp.StatusCode, "invalid Sec-WebSocket-Accept")
+	}
+
+	conn := &wsConn{rwc: rwc, br: bufio.NewReader(rwc)}
+	conn.lastSeen.Store(time.Now().UnixNano())
+	ws.mu.Lock()
+	old := ws.cur
+	ws.cur = conn
+	ws.subprotocol = resp.Header.Get("Sec-WebSocket-Protocol")
+	ws.mu.Unlock()
+	if old != nil {
+		old.close()
+	}
+
+	if ws.cfg.onConnect != nil {
+		ws.inOnConnect.Store(true)
+		err := ws.cfg.onConnect(ctx, ws)
+		ws.inOnConnect.Store(false)
+		if err != nil {
+			conn.close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) wsHandshakeError(status int, msg string) error {
+	opts := append([]errorx.Option{
+		errorx.WithMessage("websocket handshake: " + msg),
+		errorx.WithField("status", status),
+	}, c.serviceOpts()...)
+	return errorx.New(errorx.ErrWebsocketHandshake, opts...)
+}
+
+func (ws *WSConn) current() *wsConn {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.cur
+}
+
+// reconnect 连接 broken 出错后重连；broken 已被别的 goroutine 替换时直接返回
+func (ws *WSConn) reconnect(ctx context.Context, broken *wsConn, cause error) error {
+	if ws.closed.Load() {
+		return ws.closedError()
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if ws.cfg.maxReconnects == 0 || ws.inOnConnect.Load() {
+		// OnConnect 回调里的读写失败直接返回，由外层的重连循环处理
+		return cause
+	}
+
+	// 串行重连，其他 goroutine 等待结果
+	ws.reconnectMu.Lock()
+	defer ws.reconnectMu.Unlock()
+	if ws.current() != broken {
+		return nil
+	}
+	broken.close()
+	ws.c.logger.Warn(ws.ctx, logx.TagWebsocket, map[string]interface{}{
+		"event":  "disconnect",
+		logx.Err: cause.Error(),
+	})
+
+	for attempt := 0; ws.cfg.maxReconnects < 0 || attempt < ws.cfg.maxReconnects; attempt++ {
+		select {
+		case <-time.After(ws.cfg.backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ws.stop:
+			return ws.closedError()
+		}
+		if err := ws.connect(ws.ctx); err == nil {
+			if ws.closed.Load() {
+				// 重连期间被 Close
+				ws.current().close()
+				return ws.closedError()
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("websocket reconnect failed after %d attempt(s): %w", ws.cfg.maxReconnects, cause)
+}
+
+// ReadMessage 读一条完整消息（自动处理分片、ping / pong）；ctx 取消时返回 ctx.Err()，连接保持不变，
+// 没读完的消息由下一次 ReadMessage 返回
+func (ws *WSConn) ReadMessage(ctx context.Context) (WSMessageType, []byte, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		if ws.closed.Load() {
+			return 0, nil, ws.closedError()
+		}
+		conn := ws.current()
+		if ws.pending == nil || ws.pendingConn != conn {
+			// 旧连接上的读会随连接关闭而结束，结果丢弃
+			ch := make(chan wsReadResult, 1)
+			go func() {
+				typ, data, err := ws.read(conn)
+				ch <- wsReadResult{typ: typ, data: data, err: err}
+			}()
+			ws.pending, ws.pendingConn = ch, conn
+		}
+		var res wsReadResult
+		select {
+		case res = <-ws.pending:
+			ws.pending, ws.pendingConn = nil, nil
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		}
+		err := res.err
+		if err == nil {
+			return res.typ, res.data, nil
+		}
+		var ce *wsCloseError
+		if errors.As(err, &ce) && ce.code == 1000 {
+			// 服务端正常关闭，不重连
+			return 0, nil, err
+		}
+		if rerr := ws.reconnect(ctx, conn, err); rerr != nil {
+			return 0, nil, rerr
+		}
+	}
+}
+
+// WriteMessage 写一条消息
+func (ws *WSConn) WriteMessage(ctx context.Context, typ WSMessageType, data []byte) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for retried := false; ; retried = true {
+		if ws.closed.Load() {
+			return ws.closedError()
+		}
+		conn := ws.current()
+		err := conn.writeFrame(byte(typ), data)
+		if err == nil || retried {
+			return err
+		}
+		if rerr := ws.reconnect(ctx, conn, err); rerr != nil {
+			return rerr
+		}
+	}
+}
+
+// ReadJSON 读一条消息并 JSON 解码
+func (ws *WSConn) ReadJSON(ctx context.Context, v any) error {
+	_, data, err := ws.ReadMessage(ctx)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteJSON JSON 编码后以文本消息发送
+func (ws *WSConn) WriteJSON(ctx context.Context, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(ctx, WSText, data)
+}
+
+// Close 发送 close 帧并关闭连接，停止 keepalive 与重连；重复调用是安全的
+func (ws *WSConn) Close() error {
+	if ws.closed.Swap(true) {
+		return nil
+	}
+	close(ws.stop)
+	conn := ws.current()
+	var payload [2]byte
+	binary.BigEndian.PutUint16(payload[:], 1000)
+	err := conn.writeFrame(wsOpClose, payload[:])
+	conn.close()
+	ws.c.logger.Info(ws.ctx, logx.TagWebsocket, map[string]interface{}{"event": "close"})
+	return err
+}
+
+func (ws *WSConn) closedError() error {
+	return errorx.New(errorx.ErrWebsocketClosed, ws.c.serviceOpts()...)
+}
+
+// keepalive 定时 ping，超过 2 个周期没收到任何帧就断开当前连接（由读写方触发重连）
+func (ws *WSConn) keepalive() {
+	ticker := time.NewTicker(ws.cfg.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ws.stop:
+			return
+		case <-ticker.C:
+		}
+		conn := ws.current()
+		if time.Since(time.Unix(0, conn.lastSeen.Load())) > 2*ws.cfg.pingInterval {
+			ws.c.logger.Warn(ws.ctx, logx.TagWebsocket, map[string]interface{}{"event": "ping_timeout"})
+			conn.close()
+			continue
+		}
+		_ = conn.writeFrame(wsOpPing, nil)
+	}
+}
+
+// -------------------- 帧读写（RFC 6455） --------------------
+
+type wsCloseError struct {
+	code   int
+	reason string
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket closed by peer: %d %s", e.code, e.reason)
+}
+
+// writeFrame 客户端发出的帧必须加掩码
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	var header [14]byte
+	header[0] = 0x80 | op
+	n := 2
+	switch l := len(payload); {
+	case l < 126:
+		header[1] = byte(l)
+	case l <= 0xFFFF:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(l))
+		n += 2
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(l))
+		n += 8
+	}
+	header[1] |= 0x80
+	mask := header[n : n+4]
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	n += 4
+
+	buf := make([]byte, n+len(payload))
+	copy(buf, header[:n])
+	for i, b := range payload {
+		buf[n+i] = b ^ mask[i%4]
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.rwc.Write(buf)
+	return err
+}
+
+// read 读一条完整的数据消息，期间处理控制帧
+func (ws *WSConn) read(c *wsConn) (WSMessageType, []byte, error) {
+	var (
+		msgType WSMessageType
+		msg     []byte
+	)
+	for {
+		fin, op, payload, err := c.readFrame(ws.cfg.maxMessage)
+		if err != nil {
+			return 0, nil, err
+		}
+		c.lastSeen.Store(time.Now().UnixNano())
+		switch op {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			ce := &wsCloseError{code: 1005}
+			if len(payload) >= 2 {
+				ce.code = int(binary.BigEndian.Uint16(payload))
+				ce.reason = string(payload[2:])
+			}
+			_ = c.writeFrame(wsOpClose, payload[:min(len(payload), 2)])
+			c.close()
+			return 0, nil, ce
+		case wsOpContinuation:
+			if msgType == 0 {
+				return 0, nil, errors.New("websocket: unexpected continuation frame")
+			}
+		default:
+			if msgType != 0 {
+				return 0, nil, errors.New("websocket: expected continuation frame")
+			}
+			msgType = WSMessageType(op)
+		}
+		if ws.cfg.maxMessage > 0 && int64(len(msg)+len(payload)) > ws.cfg.maxMessage {
+			return 0, nil, fmt.Errorf("websocket: message exceeds %d bytes", ws.cfg.maxMessage)
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msgType, msg, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame(limit int64) (fin bool, op byte, payload []byte, err error) {
+	var h [2]byte
+	if _, err = io.ReadFull(c.br, h[:]); err != nil {
+		return
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0F
+	masked := h[1]&0x80 != 0
+	length := uint64(h[1] & 0x7F)
+	switch length {
+	case 126:
+		var b [2]byte
+		if _, err = io.ReadFull(c.br, b[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(b[:]))
+	case 127:
+		var b [8]byte
+		if _, err = io.ReadFull(c.br, b[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(b[:])
+	}
+	if limit > 0 && length > uint64(limit) {
+		err = fmt.Errorf("websocket: frame exceeds %d bytes", limit)
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
@@ -0,0 +1,222 @@
+package httpclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imattdu/orbit/tracex"
+)
+
+// wsEchoServer 最小的 WebSocket 服务端：原样回显，收到 "drop" 时直接断开 TCP，
+// 重连上来的连接先推一条 "welcome back"
+func wsEchoServer(t *testing.T, handshakes *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(tracex.HeaderTraceID) == "" {
+			t.Error("handshake without trace header")
+		}
+		n := handshakes.Add(1)
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + wsGUID))
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		_ = rw.Flush()
+
+		peer := &wsConn{rwc: conn, br: bufio.NewReader(rw)}
+		if n > 1 {
+			msg := `"welcome back"`
+			_, _ = conn.Write(append([]byte{0x81, byte(len(msg))}, msg...))
+		}
+		for {
+			_, op, payload, err := peer.readFrame(0)
+			if err != nil || string(payload) == `"drop"` {
+				return
+			}
+			if op == wsOpPing {
+				op = wsOpPong
+			}
+			_, _ = conn.Write(append([]byte{0x80 | op, byte(len(payload))}, payload...))
+		}
+	}))
+}
+
+func TestWebSocketEchoAndReconnect(t *testing.T) {
+	var handshakes, connects atomic.Int32
+	srv := wsEchoServer(t, &handshakes)
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx, _ = tracex.StartSpan(ctx, "test")
+
+	ws, err := c.DialWebSocket(ctx, &Request{Path: "/ws"},
+		WithWSReconnect(3, func(int) time.Duration { return 10 * time.Millisecond }),
+		WithWSOnConnect(func(context.Context, *WSConn) error { connects.Add(1); return nil }),
+		WithWSPing(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ws.Close() }()
+
+	echo := func(msg string) {
+		if err := ws.WriteJSON(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if err := ws.ReadJSON(ctx, &got); err != nil || got != msg {
+			t.Fatalf("echo %q: got %q err=%v", msg, got, err)
+		}
+	}
+	// 跨越多个 ping 周期，持续读的连接不应被判定为断开
+	for i := 0; i < 4; i++ {
+		echo("hello")
+		time.Sleep(40 * time.Millisecond)
+	}
+
+	// 服务端断开后，下一次读触发重连
+	if err := ws.WriteJSON(ctx, "drop"); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if err := ws.ReadJSON(ctx, &got); err != nil || got != "welcome back" {
+		t.Fatalf("read after drop: %q %v", got, err)
+	}
+	echo("after reconnect")
+	if handshakes.Load() != 2 || connects.Load() != 2 {
+		t.Fatalf("handshakes=%d connects=%d", handshakes.Load(), connects.Load())
+	}
+}
+
+// h2c client 的普通请求走 HTTP/2，握手仍需 HTTP/1.1 Upgrade
+func TestWebSocketWithH2C(t *testing.T) {
+	var handshakes atomic.Int32
+	srv := wsEchoServer(t, &handshakes)
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL), WithH2C())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx, _ = tracex.StartSpan(ctx, "test")
+
+	ws, err := c.DialWebSocket(ctx, &Request{Path: "/ws"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ws.Close() }()
+	if err := ws.WriteJSON(ctx, "h2c"); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if err := ws.ReadJSON(ctx, &got); err != nil || got != "h2c" {
+		t.Fatalf("echo: %q %v", got, err)
+	}
+}
+
+// 读超时只结束本次 ReadMessage，连接继续可用
+func TestWebSocketReadCancelKeepsConn(t *testing.T) {
+	var handshakes atomic.Int32
+	srv := wsEchoServer(t, &handshakes)
+	defer srv.Close()
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx, _ = tracex.StartSpan(ctx, "test")
+
+	ws, err := c.DialWebSocket(ctx, &Request{Path: "/ws"}, WithWSReconnect(3, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ws.Close() }()
+
+	short, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	if _, _, err := ws.ReadMessage(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded, got %v", err)
+	}
+	if err := ws.WriteJSON(ctx, "still here"); err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	if err := ws.ReadJSON(ctx, &got); err != nil || got != "still here" {
+		t.Fatalf("read after cancel: %q %v", got, err)
+	}
+	if n := handshakes.Load(); n != 1 {
+		t.Fatalf("handshakes = %d, want 1", n)
+	}
+}
+
+// 对端接受 TCP 但不完成升级时，重连握手按超时失败，而不是一直挂着
+func TestWebSocketReconnectHandshakeTimeout(t *testing.T) {
+	var handshakes atomic.Int32
+	hang := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := handshakes.Add(1)
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		if n > 1 {
+			<-hang
+			return
+		}
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + wsGUID))
+		_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		_ = rw.Flush()
+		// 握手后立即断开，触发重连
+	}))
+	defer srv.Close()
+	defer close(hang)
+
+	c, err := New(withNopLogger(), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws, err := c.DialWebSocket(context.Background(), &Request{Path: "/ws"},
+		WithWSHandshakeTimeout(100*time.Millisecond),
+		WithWSReconnect(2, func(int) time.Duration { return time.Millisecond }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ws.Close() }()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := ws.ReadMessage(context.Background())
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("want reconnect error")
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("reconnect handshake hung")
+	}
+	if n := handshakes.Load(); n != 3 {
+		t.Fatalf("handshakes = %d, want 3", n)
+	}
+}
@@ -1,17 +1,50 @@
 package logx
 
-import "log/slog"
+import (
+	"log/slog"
+	"time"
+)
 
 type RotateMode int
 
 const (
-	RotateHourly RotateMode = iota // 按小时切
-	RotateSize                     // 按大小切
+	RotateHourly   RotateMode = iota // 按小时切
+	RotateSize                       // 按大小切
+	RotateDaily                      // 按天切
+	RotatePattern                    // 按 RotatePattern 切：格式化结果变化即切
+	RotateTimeSize                   // 按 RotatePattern 切，周期内超过 MaxFileSizeMB 再按序号切
+)
+
+// OverflowPolicy 异步队列满时的处理策略
+type OverflowPolicy int
+
+const (
+	OverflowDropNewest   OverflowPolicy = iota // 丢弃新日志（默认，不阻塞业务）
+	OverflowDropOldest                         // 丢弃队首最旧的日志，保留新日志
+	OverflowBlockTimeout                       // 阻塞等待 BlockTimeout，超时丢弃新日志
+	OverflowBlock                              // 一直阻塞直到入队（审计日志，一条都不能丢）
 )
 
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowBlockTimeout:
+		return "block_timeout"
+	case OverflowBlock:
+		return "block"
+	default:
+		return "unknown"
+	}
+}
+
 type Config struct {
 	AppName string     // 应用名，用于文件名前缀
 	Level   slog.Level // 最小日志级别
+	// 运行时级别控制器，可在多个 logger 间共享；nil 时按 Level 新建（用 LevelsOf 获取）
+	Levels *LevelController
 
 	LogDir string // 日志目录
 
@@ -19,12 +52,37 @@ type Config struct {
 	ConsoleColored bool // 控制台是否彩色输出
 
 	Rotate *RotateMode // 滚动模式
+	// RotatePattern / RotateTimeSize 用：strftime 风格（%Y %y %m %d %H %M %S %j），默认 "%Y%m%d%H"；
+	// 必须包含比最细单位更粗的所有单位（如用 %H 时要有年和日期），保证周期不会重复
+	RotatePattern string
 
-	// RotateSize 模式用：超过 size 就切新文件
+	// RotateSize / RotateTimeSize 用：超过 size 就切新文件（同一周期内序号递增：app-2026101615.1.log）
 	MaxFileSizeMB int
-	// 最多保留多少个历史文件（按修改时间排序）
+	// 最多保留多少个历史文件（info / warn 分别计数，按修改时间排序）
 	MaxBackups int
+	// 历史文件最长保留时间，0 不限
+	MaxAge time.Duration
+	// info + warn 文件（含当前文件）的总大小上限，超出时从最旧的历史文件删起，0 不限
+	MaxTotalSizeMB int
+	// 切分后的历史文件在后台压缩，nil 不压缩；内置 GzipCompressor，zstd 等实现 Compressor 即可接入
+	Compressor Compressor
 
 	// 异步队列大小（<=0 使用默认 10000）
 	QueueSize int
+	// 队列满时的策略，默认 OverflowDropNewest
+	Overflow OverflowPolicy
+	// OverflowBlockTimeout 的最长等待（<=0 使用默认 100ms）
+	BlockTimeout time.Duration
+	// 丢弃汇总的周期：有丢弃时写一条 "dropped N records in last 10s"（0 使用默认 10s，<0 不写）
+	DropReportInterval time.Duration
+
+	// 采样规则，按 tag + 级别限制每秒条数（如前 100 条全留，之后每 10 条留 1 条）
+	Sampling []SampleRule
+	// Error 及以上级别不参与采样（仍参与去重）
+	SamplingBypassErrors bool
+	// >0 开启去重：窗口内 tag + msg + 调用位置相同的日志只写第一条，窗口结束再写一条带 repeated=N 的汇总
+	DedupWindow time.Duration
+
+	// Close 之后的日志：false 直接丢弃，true 同步写到 stderr（进程退出阶段的日志不丢）
+	SyncAfterClose bool
 }
@@ -6,6 +6,9 @@ const (
 	TagRequestOut   = "request_out"
 	TagHttpSuccess  = "http_success"
 	TagHttpFailure  = "http_failure"
+	TagHttpShadow   = "http_shadow"
+	TagWebsocket    = "websocket"
+	TagLogDropped   = "log_dropped"
 	TagMysqlSuccess = "mysql_success"
 	TagMysqlFailure = "mysql_failure"
 	TagRedisSuccess = "redis_success"
@@ -29,4 +32,18 @@ const (
 	Attempt     = "attempt"
 	Attempts    = "attempts"
 	MaxAttempts = "max_attempts"
+
+	IdempotencyKey = "idempotency_key"
+	Fault          = "fault"
+	Limit          = "limit"
+	Curl           = "curl"
+	Proxy          = "proxy"
+
+	Dropped      = "dropped"
+	DroppedDebug = "dropped_debug"
+	DroppedInfo  = "dropped_info"
+	DroppedWarn  = "dropped_warn"
+	DroppedError = "dropped_error"
+	Policy       = "policy"
+	Repeated     = "repeated"
 )
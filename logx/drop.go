@@ -0,0 +1,93 @@
+package logx
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// DropStats 按级别统计被丢弃的日志条数
+type DropStats struct {
+	Debug int64 `json:"debug"`
+	Info  int64 `json:"info"`
+	Warn  int64 `json:"warn"`
+	Error int64 `json:"error"`
+}
+
+// Total 丢弃总数
+func (s DropStats) Total() int64 {
+	return s.Debug + s.Info + s.Warn + s.Error
+}
+
+// DroppedOf 返回 logger 自创建以来累计丢弃的日志数（非 logx.New 创建的 logger 返回零值）
+func DroppedOf(l Logger) DropStats {
+	li, ok := l.(*loggerImpl)
+	if !ok || li == nil || li.slog == nil {
+		return DropStats{}
+	}
+	if h, ok := li.slog.Handler().(*handler); ok {
+		return h.total.load()
+	}
+	return DropStats{}
+}
+
+// dropCounter 按级别计数：debug / info / warn / error
+type dropCounter [4]atomic.Int64
+
+func levelIndex(l slog.Level) int {
+	switch {
+	case l < slog.LevelInfo:
+		return 0
+	case l < slog.LevelWarn:
+		return 1
+	case l < slog.LevelError:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func (c *dropCounter) add(l slog.Level) {
+	c[levelIndex(l)].Add(1)
+}
+
+func (c *dropCounter) load() DropStats {
+	return DropStats{Debug: c[0].Load(), Info: c[1].Load(), Warn: c[2].Load(), Error: c[3].Load()}
+}
+
+// swap 取出当前计数并清零
+func (c *dropCounter) swap() DropStats {
+	return DropStats{Debug: c[0].Swap(0), Info: c[1].Swap(0), Warn: c[2].Swap(0), Error: c[3].Swap(0)}
+}
+
+// drop 记一次丢弃：窗口计数用于汇总日志，累计计数用于 DroppedOf
+func (h *handler) drop(l slog.Level) {
+	h.window.add(l)
+	h.total.add(l)
+}
+
+// reportDropped 把上个周期的丢弃情况作为一条 warn 日志写入文件，没有丢弃时不写
+func (h *handler) reportDropped(since time.Time) {
+	s := h.window.swap()
+	n := s.Total()
+	if n == 0 {
+		return
+	}
+	now := time.Now()
+	rec := slog.NewRecord(now, slog.LevelWarn, "", 0)
+	rec.AddAttrs(
+		slog.String("tag", TagLogDropped),
+		slog.String(Msg, fmt.Sprintf("dropped %d records in last %s", n, now.Sub(since).Round(time.Second))),
+		slog.Int64(Dropped, n),
+		slog.Int64(DroppedDebug, s.Debug),
+		slog.Int64(DroppedInfo, s.Info),
+		slog.Int64(DroppedWarn, s.Warn),
+		slog.Int64(DroppedError, s.Error),
+		slog.String(Policy, h.cfg.Overflow.String()),
+	)
+	if err := h.writeRecord(rec); err != nil {
+		log.Println("write log failed:", err)
+	}
+}
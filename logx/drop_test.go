@@ -0,0 +1,174 @@
+package logx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stalledLogger 返回一个写线程被卡住的 logger（持有 handler.mu），调用 release 恢复
+func stalledLogger(t *testing.T, cfg Config) (Logger, *handler, string, func()) {
+	t.Helper()
+	cfg.QueueSize = 1
+	l, dir := newTestLogger(t, cfg)
+	h := l.(*loggerImpl).slog.Handler().(*handler)
+	h.mu.Lock()
+	return l, h, dir, h.mu.Unlock
+}
+
+func TestOverflowDrop(t *testing.T) {
+	ctx := context.Background()
+	for _, p := range []OverflowPolicy{OverflowDropNewest, OverflowDropOldest, OverflowBlockTimeout} {
+		t.Run(p.String(), func(t *testing.T) {
+			l, _, dir, release := stalledLogger(t, Config{Overflow: p, BlockTimeout: 10 * time.Millisecond})
+			for i := range 10 {
+				l.Info(ctx, TagUndef, fmt.Sprintf("m%d", i))
+			}
+			l.Error(ctx, TagUndef, "e0")
+			release()
+			if err := l.Close(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			s := DroppedOf(l)
+			if s.Info < 8 || s.Error > 1 || s.Total() < 9 {
+				t.Fatalf("dropped %+v", s)
+			}
+			info, warn := readLog(t, dir, "test.log"), readLog(t, dir, "test.wf.log")
+			if p == OverflowDropOldest && !strings.Contains(warn, `"msg":"e0"`) {
+				t.Fatal("drop oldest lost the newest record")
+			}
+			if strings.Count(info, `"msg":"m`) > 2 {
+				t.Fatalf("too many records written:\n%s", info)
+			}
+			want := fmt.Sprintf(`"dropped":%d`, s.Total())
+			if !strings.Contains(warn, want) || !strings.Contains(warn, `"tag":"log_dropped"`) {
+				t.Fatalf("missing drop summary %s:\n%s", want, warn)
+			}
+		})
+	}
+}
+
+func TestDropOldestKeepsFlush(t *testing.T) {
+	ctx := context.Background()
+	l, _, _, release := stalledLogger(t, Config{Overflow: OverflowDropOldest})
+	l.Info(ctx, TagUndef, "m0")
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- l.Flush(ctx) }()
+
+	// 队列满时持续挤掉旧日志，既不能卡住写日志的一方，也不能丢掉 Flush 请求
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				l.Info(ctx, TagUndef, fmt.Sprintf("m%d", i))
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drop oldest blocked the caller")
+	}
+	release()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("flush never acknowledged")
+	}
+	if err := l.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverflowBlock(t *testing.T) {
+	ctx := context.Background()
+	l, _, dir, release := stalledLogger(t, Config{Overflow: OverflowBlock})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range 5 {
+			l.Info(ctx, TagUndef, fmt.Sprintf("m%d", i))
+		}
+	}()
+	select {
+	case <-done:
+		t.Fatal("block policy did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	<-done
+	if err := l.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := DroppedOf(l); s.Total() != 0 {
+		t.Fatalf("dropped %+v", s)
+	}
+	if n := strings.Count(readLog(t, dir, "test.log"), `"msg":"m`); n != 5 {
+		t.Fatalf("wrote %d records, want 5", n)
+	}
+}
+
+// 阻塞在入队上的 Handle 不能卡住 Close，也不能让之后的日志调用排在 Close 后面一起卡住
+func TestOverflowBlockClose(t *testing.T) {
+	l, _, _, release := stalledLogger(t, Config{Overflow: OverflowBlock})
+	defer release()
+
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		for i := range 5 {
+			l.Info(context.Background(), TagUndef, fmt.Sprintf("m%d", i))
+		}
+	}()
+	select {
+	case <-blocked:
+		t.Fatal("block policy did not block")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	closed := make(chan error, 1)
+	go func() { closed <- l.Close(ctx) }()
+	select {
+	case err := <-closed:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("want DeadlineExceeded while the writer is stalled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked behind a blocked Handle")
+	}
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("blocked Handle not released by Close")
+	}
+	done := make(chan struct{})
+	go func() {
+		l.Info(context.Background(), TagUndef, "after close")
+		_ = l.Flush(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("logging after Close blocked")
+	}
+}
@@ -7,9 +7,7 @@ import (
 	"log"
 	"log/slog"
 	"os"
-	"path/filepath"
-	"sort"
-	"strings"
+	"regexp"
 	"sync"
 	"time"
 )
@@ -19,15 +17,33 @@ type handler struct {
 
 	mu sync.Mutex
 
-	infoFile *os.File
-	warnFile *os.File
-
-	infoSize int64
-	warnSize int64
-
-	curHr time.Time // RotateHourly 使用：当前小时
+	info logFile
+	warn logFile
 
 	entries chan slog.Record
+	flushes chan chan error // Flush 请求，单独走一个 channel，不会被 drop oldest 挤掉
+
+	// closeMu 保护 closed 与 entries 的关闭，Handle 持读锁发送
+	closeMu  sync.RWMutex
+	closed   bool
+	closing  chan struct{} // Close 开始时关闭，让持读锁阻塞发送的 Handle / Flush 放弃等待、释放读锁
+	closeOne sync.Once
+	done     chan struct{} // writeLoop 写完并关闭文件后关闭
+	closeErr error         // 关闭文件的错误，done 关闭后可读
+
+	window dropCounter // 当前汇总周期内的丢弃数
+	total  dropCounter // 累计丢弃数
+
+	levels  *LevelController
+	sampler *sampler // 未配置采样时为 nil
+	dedup   *deduper // 未开启去重时为 nil
+
+	// 后台压缩 / 清理历史文件，未配置时为 nil；infoName / warnName 识别本 logger 的历史文件
+	infoName *regexp.Regexp
+	warnName *regexp.Regexp
+	tidyKick chan struct{}
+	tidyStop chan struct{}
+	tidyDone chan struct{}
 }
 
 func newHandler(cfg Config) (slog.Handler, error) {
@@ -40,10 +56,35 @@ func newHandler(cfg Config) (slog.Handler, error) {
 	if cfg.LogDir == "" {
 		cfg.LogDir = "."
 	}
+	if cfg.Levels == nil {
+		cfg.Levels = NewLevelController(cfg.Level)
+	}
+	if cfg.BlockTimeout <= 0 {
+		cfg.BlockTimeout = 100 * time.Millisecond
+	}
+	if cfg.DropReportInterval == 0 {
+		cfg.DropReportInterval = 10 * time.Second
+	}
+
+	switch *cfg.Rotate {
+	case RotatePattern, RotateTimeSize:
+		if cfg.RotatePattern != "" {
+			if err := validatePattern(cfg.RotatePattern); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	h := &handler{
 		cfg:     cfg,
+		warn:    logFile{warn: true},
 		entries: make(chan slog.Record, cfg.QueueSize),
+		flushes: make(chan chan error),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+		levels:  cfg.Levels,
+		sampler: newSampler(cfg),
+		dedup:   newDeduper(cfg),
 	}
 
 	now := time.Now()
@@ -56,24 +97,122 @@ func newHandler(cfg Config) (slog.Handler, error) {
 	}
 	h.mu.Unlock()
 
+	if h.needJanitor() {
+		h.infoName = h.nameRegexp(h.infoPrefix())
+		h.warnName = h.nameRegexp(h.warnPrefix())
+		h.tidyKick = make(chan struct{}, 1)
+		h.tidyStop = make(chan struct{})
+		h.tidyDone = make(chan struct{})
+		go h.janitorLoop()
+		h.kickJanitor()
+	}
 	go h.writeLoop()
 	return h, nil
 }
 
-// Enabled 满足 slog.Handler 接口
+// Enabled 满足 slog.Handler 接口：只按所有级别设置中的最小值粗筛，tag / 调用位置的覆盖在 Handle 里判断
 func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
-	return level >= h.cfg.Level
+	return level >= h.levels.minLevel()
 }
 
-// Handle 只负责把 Record 推入异步队列，队列满则丢（不阻塞业务）
+// Handle 经过去重、采样后把 Record 推入异步队列，队列满时按 Config.Overflow 处理；
+// Close 之后按 Config.SyncAfterClose 丢弃或同步写 stderr
 func (h *handler) Handle(_ context.Context, r slog.Record) error {
-	rr := r.Clone()
+	h.closeMu.RLock()
+	defer h.closeMu.RUnlock()
+	if h.closed {
+		if h.cfg.SyncAfterClose {
+			return h.writeStderr(r)
+		}
+		return nil
+	}
+	if !h.levelEnabled(r) {
+		return nil
+	}
+	ok, sum := h.admit(r)
+	if sum != nil {
+		h.enqueue(*sum)
+	}
+	if ok {
+		h.enqueue(r.Clone())
+	}
+	return nil
+}
+
+// levelEnabled 按级别控制器判断，只有存在覆盖时才需要取 tag / file
+func (h *handler) levelEnabled(r slog.Record) bool {
+	if !h.levels.hasOverrides() {
+		return r.Level >= h.levels.Level()
+	}
+	var tag, file string
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "tag":
+			tag = a.Value.String()
+		case "file":
+			file = a.Value.String()
+		}
+		return true
+	})
+	return h.levels.Enabled(r.Level, tag, file)
+}
+
+// enqueue 入队，队列满时按 Config.Overflow 处理；调用方持有 closeMu 读锁，
+// 阻塞等待时同时等 closing，否则 Close 拿不到写锁，之后的读锁也会排在它后面
+func (h *handler) enqueue(r slog.Record) {
 	select {
-	case h.entries <- rr:
+	case h.entries <- r:
+		return
 	default:
-		log.Println("log queue full, drop log")
 	}
-	return nil
+
+	switch h.cfg.Overflow {
+	case OverflowDropOldest:
+		h.enqueueDropOldest(r)
+	case OverflowBlockTimeout:
+		t := time.NewTimer(h.cfg.BlockTimeout)
+		defer t.Stop()
+		select {
+		case h.entries <- r:
+		case <-t.C:
+			h.drop(r.Level)
+		case <-h.closing:
+			h.dropOnClose(r)
+		}
+	case OverflowBlock:
+		select {
+		case h.entries <- r:
+		case <-h.closing:
+			h.dropOnClose(r)
+		}
+	default:
+		h.drop(r.Level)
+	}
+}
+
+// dropOnClose 等待入队时遇到 Close：按 Config.SyncAfterClose 同步写 stderr，否则计入丢弃
+func (h *handler) dropOnClose(r slog.Record) {
+	if h.cfg.SyncAfterClose && h.writeStderr(r) == nil {
+		return
+	}
+	h.drop(r.Level)
+}
+
+// enqueueDropOldest 挤掉队首的日志腾出位置，几次都抢不到位置时按 drop newest 处理
+func (h *handler) enqueueDropOldest(r slog.Record) {
+	for range 3 {
+		select {
+		case old := <-h.entries:
+			h.drop(old.Level)
+		default:
+		}
+		select {
+		case h.entries <- r:
+			return
+		default:
+		}
+	}
+	h.drop(r.Level)
 }
 
 func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
@@ -88,18 +227,173 @@ func (h *handler) WithGroup(name string) slog.Handler {
 	return h
 }
 
-// 异步写 loop
+// 异步写 loop，定期汇总丢弃情况、写出到期的去重汇总、清理过期的采样计数；entries 关闭且写完后 fsync 并关闭文件，等后台整理停下后退出
 func (h *handler) writeLoop() {
-	for rec := range h.entries {
-		if err := h.writeRecord(rec); err != nil {
-			log.Println("write log failed:", err)
+	defer close(h.done)
+
+	var tick <-chan time.Time
+	if h.cfg.DropReportInterval > 0 {
+		t := time.NewTicker(h.cfg.DropReportInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+	var dedupTick <-chan time.Time
+	if h.dedup != nil {
+		t := time.NewTicker(h.dedup.window)
+		defer t.Stop()
+		dedupTick = t.C
+	}
+	var sampleTick <-chan time.Time
+	if h.sampler != nil {
+		t := time.NewTicker(h.sampler.interval)
+		defer t.Stop()
+		sampleTick = t.C
+	}
+	since := time.Now()
+	for {
+		select {
+		case r, ok := <-h.entries:
+			if !ok {
+				h.flushDedup(time.Now(), true)
+				if tick != nil {
+					h.reportDropped(since)
+				}
+				h.closeErr = h.closeFiles()
+				if h.tidyStop != nil {
+					close(h.tidyStop)
+					<-h.tidyDone
+				}
+				return
+			}
+			h.write(r)
+		case ack := <-h.flushes:
+			// 请求之前已入队的日志都还在队列里（或已被 drop oldest 挤掉），写完再 fsync
+			h.drain(len(h.entries))
+			ack <- h.sync()
+		case <-tick:
+			h.reportDropped(since)
+			since = time.Now()
+		case now := <-dedupTick:
+			h.flushDedup(now, false)
+		case now := <-sampleTick:
+			h.sampler.expire(now)
 		}
 	}
 }
 
-// writeRecord 把 Record 编码成 JSON 一行，写入 info/warn 文件 + 控制台
-func (h *handler) writeRecord(r slog.Record) error {
-	// 先构造 JSON 行，减少持锁时间
+// drain 最多写出队列里的 n 条日志；队列空了（被 drop oldest 挤掉）或已关闭时提前返回
+func (h *handler) drain(n int) {
+	for ; n > 0; n-- {
+		select {
+		case r, ok := <-h.entries:
+			if !ok {
+				return
+			}
+			h.write(r)
+		default:
+			return
+		}
+	}
+}
+
+func (h *handler) write(r slog.Record) {
+	if err := h.writeRecord(r); err != nil {
+		log.Println("write log failed:", err)
+	}
+}
+
+// Flush 等待调用前已入队的日志全部写完并 fsync；ctx 到期时返回 ctx.Err()，已入队的日志仍会写出
+func (h *handler) Flush(ctx context.Context) error {
+	ack := make(chan error, 1)
+	h.closeMu.RLock()
+	if h.closed {
+		h.closeMu.RUnlock()
+		return nil
+	}
+	select {
+	case h.flushes <- ack:
+		h.closeMu.RUnlock()
+	case <-h.closing:
+		// Close 会写完队列并 fsync
+		h.closeMu.RUnlock()
+		return nil
+	case <-ctx.Done():
+		h.closeMu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-ack:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 停止接收新日志，等队列写完后 fsync 并关闭文件；
+// ctx 到期时不再等待并返回 ctx.Err()，剩余日志由 writeLoop 在后台写完。重复调用是安全的
+func (h *handler) Close(ctx context.Context) error {
+	h.closeOne.Do(func() { close(h.closing) })
+	h.closeMu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.entries)
+	}
+	h.closeMu.Unlock()
+
+	select {
+	case <-h.done:
+		return h.closeErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sync fsync 当前的 info / warn 文件
+func (h *handler) sync() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var err error
+	for _, f := range []*os.File{h.info.f, h.warn.f} {
+		if f == nil {
+			continue
+		}
+		if e := f.Sync(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// closeFiles fsync 并关闭 info / warn 文件
+func (h *handler) closeFiles() error {
+	err := h.sync()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, lf := range []*logFile{&h.info, &h.warn} {
+		if lf.f == nil {
+			continue
+		}
+		if e := lf.f.Close(); e != nil && err == nil {
+			err = e
+		}
+		lf.f = nil
+	}
+	return err
+}
+
+// writeStderr Close 之后的同步兜底：不再碰日志文件，直接写 stderr
+func (h *handler) writeStderr(r slog.Record) error {
+	line, err := encodeLine(r)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stderr.WriteString(line)
+	return err
+}
+
+// encodeLine 把 Record 编码成 JSON 一行
+func encodeLine(r slog.Record) (string, error) {
 	data := make(map[string]any, 16)
 	data["ts"] = r.Time.Format(time.RFC3339Nano)
 	data["level"] = r.Level.String()
@@ -111,10 +405,19 @@ func (h *handler) writeRecord(r slog.Record) error {
 	})
 
 	lineBytes, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(lineBytes) + "\n", nil
+}
+
+// writeRecord 把 Record 编码成 JSON 一行，写入 info/warn 文件 + 控制台
+func (h *handler) writeRecord(r slog.Record) error {
+	// 先构造 JSON 行，减少持锁时间
+	line, err := encodeLine(r)
 	if err != nil {
 		return err
 	}
-	line := string(lineBytes) + "\n"
 
 	now := time.Now()
 
@@ -126,22 +429,16 @@ func (h *handler) writeRecord(r slog.Record) error {
 	}
 
 	// 选择 info / warn 文件
-	var f *os.File
+	lf := &h.info
 	if r.Level >= slog.LevelWarn {
-		f = h.warnFile
-	} else {
-		f = h.infoFile
+		lf = &h.warn
 	}
-	if f != nil {
-		n, err := f.WriteString(line)
+	if lf.f != nil {
+		n, err := lf.f.WriteString(line)
+		lf.size += int64(n)
 		if err != nil {
 			return err
 		}
-		if r.Level >= slog.LevelWarn {
-			h.warnSize += int64(n)
-		} else {
-			h.infoSize += int64(n)
-		}
 	}
 
 	// 控制台输出
@@ -156,116 +453,6 @@ func (h *handler) writeRecord(r slog.Record) error {
 	return nil
 }
 
-// rotateIfNeededLocked 在已上锁的情况下，根据配置判断是否需要切分 info/warn 文件
-func (h *handler) rotateIfNeededLocked(now time.Time) error {
-	needNew := false
-	needWarnNew := false
-	switch *h.cfg.Rotate {
-	case RotateHourly:
-		// 按小时切
-		hour := now.Truncate(time.Hour)
-		if h.curHr.IsZero() || !hour.Equal(h.curHr) {
-			needNew = true
-			needWarnNew = true
-			h.curHr = hour
-			h.infoSize = 0
-			h.warnSize = 0
-		}
-		if h.infoFile == nil {
-			needNew = true
-		}
-		if h.warnFile == nil {
-			needWarnNew = true
-		}
-	case RotateSize:
-		if h.infoFile == nil {
-			needNew = true
-		}
-		if h.warnFile == nil {
-			needWarnNew = true
-		}
-		if h.cfg.MaxFileSizeMB > 0 {
-			limit := int64(h.cfg.MaxFileSizeMB) * 1024 * 1024
-			if h.infoSize >= limit {
-				needNew = true
-				h.infoSize = 0
-			}
-			if h.warnSize >= limit {
-				needWarnNew = true
-				h.warnSize = 0
-			}
-		}
-	}
-
-	if !needNew && !needWarnNew {
-		return nil
-	}
-
-	// 关闭旧文件
-	if needNew && h.infoFile != nil {
-		_ = h.infoFile.Close()
-		h.infoFile = nil
-	}
-	if needWarnNew && h.warnFile != nil {
-		_ = h.warnFile.Close()
-		h.warnFile = nil
-	}
-
-	if err := os.MkdirAll(h.cfg.LogDir, 0o755); err != nil {
-		return err
-	}
-	// 打开新的 info / warn 文件
-	if needNew {
-		infoName := h.buildFilename(now, false)
-		infoFile, err := os.OpenFile(infoName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
-		if err != nil {
-			return err
-		}
-		h.infoFile = infoFile
-		infoLink := filepath.Join(h.cfg.LogDir, h.cfg.AppName+".log")
-		_ = os.Remove(infoLink)
-		_ = os.Symlink(filepath.Base(infoName), infoLink)
-	}
-
-	if needWarnNew {
-		warnName := h.buildFilename(now, true)
-		warnFile, err := os.OpenFile(warnName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
-		if err != nil {
-			return err
-		}
-		h.warnFile = warnFile
-		warnLink := filepath.Join(h.cfg.LogDir, h.cfg.AppName+".wf.log")
-
-		_ = os.Remove(warnLink)
-		_ = os.Symlink(filepath.Base(warnName), warnLink)
-	}
-
-	// 清理旧文件
-	if h.cfg.MaxBackups > 0 {
-		h.cleanupOldFiles(h.infoPrefix())
-		h.cleanupOldFiles(h.warnPrefix())
-	}
-	return nil
-}
-
-// buildFilename 构造 info / warn 日志文件名
-func (h *handler) buildFilename(now time.Time, warn bool) string {
-	var ts string
-	if *h.cfg.Rotate == RotateSize {
-		// 按大小切时，也带上日期，方便排查
-		ts = now.Format("20060102150405") // 到秒
-	} else {
-		ts = now.Format("2006010215") // 到小时
-	}
-
-	name := h.cfg.AppName
-	if warn {
-		// warn 文件加 .wf 前缀，和常见 app.wf.log 习惯一致
-		return filepath.Join(h.cfg.LogDir, fmt.Sprintf("%s.wf-%s.log", name, ts))
-	}
-	return filepath.Join(h.cfg.LogDir, fmt.Sprintf("%s-%s.log", name, ts))
-}
-
 func (h *handler) infoPrefix() string {
 	return h.cfg.AppName + "-"
 }
@@ -274,53 +461,6 @@ func (h *handler) warnPrefix() string {
 	return h.cfg.AppName + ".wf-"
 }
 
-// cleanupOldFiles 只清理指定前缀的日志文件（info 或 warn）
-func (h *handler) cleanupOldFiles(prefix string) {
-	entries, err := os.ReadDir(h.cfg.LogDir)
-	if err != nil {
-		log.Println("cleanupOldFiles ReadDir error:", err)
-		return
-	}
-
-	suffix := ".log"
-
-	type fi struct {
-		name string
-		t    time.Time
-	}
-
-	files := make([]fi, 0, len(entries))
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		name := e.Name()
-		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
-			continue
-		}
-		info, err := e.Info()
-		if err != nil {
-			continue
-		}
-		files = append(files, fi{
-			name: filepath.Join(h.cfg.LogDir, name),
-			t:    info.ModTime(),
-		})
-	}
-
-	if len(files) <= h.cfg.MaxBackups {
-		return
-	}
-
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].t.After(files[j].t) // 新的在前
-	})
-
-	for _, f := range files[h.cfg.MaxBackups:] {
-		_ = os.Remove(f.name)
-	}
-}
-
 // colorLine 简单根据 level 加点前缀颜色（用现成的 JSON 行）
 func (h *handler) colorLine(r slog.Record, line string) string {
 	level := r.Level.String()
@@ -0,0 +1,73 @@
+package logx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(t *testing.T, cfg Config) (Logger, string) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg.AppName = "test"
+	cfg.LogDir = dir
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, dir
+}
+
+func readLog(t *testing.T, dir, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestFlushAndClose(t *testing.T) {
+	l, dir := newTestLogger(t, Config{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for range 100 {
+		l.Info(ctx, TagUndef, "before flush")
+	}
+	l.Warn(ctx, TagUndef, "warn before flush")
+	if err := l.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(readLog(t, dir, "test.log"), "before flush"); n != 100 {
+		t.Fatalf("flushed %d info lines, want 100", n)
+	}
+	if !strings.Contains(readLog(t, dir, "test.wf.log"), "warn before flush") {
+		t.Fatal("warn line not flushed")
+	}
+
+	for range 100 {
+		l.Info(ctx, TagUndef, "before close")
+	}
+	if err := l.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(readLog(t, dir, "test.log"), "before close"); n != 100 {
+		t.Fatalf("closed with %d lines written, want 100", n)
+	}
+
+	// Close 之后：写入是 no-op，不会重新打开文件；Flush / Close 可重复调用
+	l.Info(ctx, TagUndef, "after close")
+	if err := l.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(readLog(t, dir, "test.log"), "after close") {
+		t.Fatal("record written after close")
+	}
+}
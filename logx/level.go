@@ -0,0 +1,358 @@
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LevelController 运行时可调整的日志级别：全局级别 + 按 tag 覆盖 + 按文件路径前缀（包目录）覆盖。
+// 优先级：tag 覆盖 > 最长匹配的前缀覆盖 > 全局级别；设置时可带 TTL，到期自动恢复原值。
+// 读路径无锁，可以被多个 logger 共享
+type LevelController struct {
+	mu    sync.Mutex // 串行化修改
+	gen   uint64     // 每次修改递增，TTL 恢复时用来判断是否已被后续修改覆盖
+	state atomic.Pointer[levelState]
+}
+
+// levelState 不可变快照，修改时整体替换
+type levelState struct {
+	global   levelOverride
+	tags     map[string]levelOverride
+	prefixes []prefixOverride // 按前缀长度降序
+	min      slog.Level       // 所有级别中的最小值，Enabled 快速判断用
+}
+
+type levelOverride struct {
+	level   slog.Level
+	expires time.Time
+	gen     uint64
+	prev    *levelOverride // 带 TTL 时到期恢复成它，nil 表示恢复为没有覆盖
+}
+
+// withPrev 带 TTL 的设置记住被覆盖的值，永久设置不会恢复，不必保留
+func (o levelOverride) withPrev(prev *levelOverride) levelOverride {
+	if !o.expires.IsZero() {
+		o.prev = prev.live(time.Now())
+	}
+	return o
+}
+
+// live 沿恢复链找到 now 时仍然有效的设置：已经过期的不能再恢复回来，改用它自己会恢复成的值
+func (o *levelOverride) live(now time.Time) *levelOverride {
+	for o != nil && !o.expires.IsZero() && !now.Before(o.expires) {
+		o = o.prev
+	}
+	return o
+}
+
+type prefixOverride struct {
+	prefix string
+	levelOverride
+}
+
+// NewLevelController 以 level 为全局级别创建
+func NewLevelController(level slog.Level) *LevelController {
+	c := &LevelController{}
+	c.state.Store(&levelState{global: levelOverride{level: level}, min: level})
+	return c
+}
+
+// Level 当前全局级别
+func (c *LevelController) Level() slog.Level {
+	return c.state.Load().global.level
+}
+
+// SetLevel 修改全局级别，ttl > 0 时到期恢复为修改前的级别
+func (c *LevelController) SetLevel(level slog.Level, ttl time.Duration) {
+	c.update(level, ttl, func(s *levelState, o levelOverride) func(*levelState) {
+		prev := s.global
+		o = o.withPrev(&prev)
+		s.global = o
+		return func(s *levelState) {
+			if s.global.gen != o.gen {
+				return
+			}
+			// 全局级别的恢复链以一个不过期的设置结尾
+			if p := o.prev.live(time.Now()); p != nil {
+				s.global = *p
+			}
+		}
+	})
+}
+
+// SetTagLevel 覆盖某个 tag 的级别，ttl > 0 时到期恢复
+func (c *LevelController) SetTagLevel(tag string, level slog.Level, ttl time.Duration) {
+	c.setOverride(tag, "", level, ttl)
+}
+
+// SetPrefixLevel 覆盖调用位置以 prefix 开头的日志的级别（如 "httpclient/" 对应整个包），ttl > 0 时到期恢复
+func (c *LevelController) SetPrefixLevel(prefix string, level slog.Level, ttl time.Duration) {
+	c.setOverride("", prefix, level, ttl)
+}
+
+// ResetTag 删除 tag 覆盖
+func (c *LevelController) ResetTag(tag string) {
+	c.update(0, 0, func(s *levelState, _ levelOverride) func(*levelState) {
+		delete(s.tags, tag)
+		return nil
+	})
+}
+
+// ResetPrefix 删除前缀覆盖
+func (c *LevelController) ResetPrefix(prefix string) {
+	c.update(0, 0, func(s *levelState, _ levelOverride) func(*levelState) {
+		s.prefixes = slices.DeleteFunc(s.prefixes, func(p prefixOverride) bool { return p.prefix == prefix })
+		return nil
+	})
+}
+
+// Enabled 判断某级别、tag、调用文件的日志是否输出
+func (c *LevelController) Enabled(level slog.Level, tag, file string) bool {
+	s := c.state.Load()
+	if level < s.min {
+		return false
+	}
+	if o, ok := s.tags[tag]; ok {
+		return level >= o.level
+	}
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(file, p.prefix) {
+			return level >= p.level
+		}
+	}
+	return level >= s.global.level
+}
+
+// minLevel 所有级别中的最小值，低于它的日志无需再看 tag / 调用位置
+func (c *LevelController) minLevel() slog.Level {
+	return c.state.Load().min
+}
+
+// hasOverrides 是否存在 tag / 前缀覆盖
+func (c *LevelController) hasOverrides() bool {
+	s := c.state.Load()
+	return len(s.tags) > 0 || len(s.prefixes) > 0
+}
+
+func (c *LevelController) setOverride(tag, prefix string, level slog.Level, ttl time.Duration) {
+	c.update(level, ttl, func(s *levelState, o levelOverride) func(*levelState) {
+		if prefix == "" {
+			if prev, had := s.tags[tag]; had {
+				o = o.withPrev(&prev)
+			}
+			s.tags[tag] = o
+			return func(s *levelState) {
+				if cur, ok := s.tags[tag]; !ok || cur.gen != o.gen {
+					return
+				}
+				if p := o.prev.live(time.Now()); p != nil {
+					s.tags[tag] = *p
+				} else {
+					delete(s.tags, tag)
+				}
+			}
+		}
+
+		idx := slices.IndexFunc(s.prefixes, func(p prefixOverride) bool { return p.prefix == prefix })
+		if idx >= 0 {
+			prev := s.prefixes[idx].levelOverride
+			o = o.withPrev(&prev)
+			s.prefixes[idx].levelOverride = o
+		} else {
+			s.prefixes = append(s.prefixes, prefixOverride{prefix: prefix, levelOverride: o})
+		}
+		return func(s *levelState) {
+			i := slices.IndexFunc(s.prefixes, func(p prefixOverride) bool { return p.prefix == prefix })
+			if i < 0 || s.prefixes[i].gen != o.gen {
+				return
+			}
+			if p := o.prev.live(time.Now()); p != nil {
+				s.prefixes[i].levelOverride = *p
+			} else {
+				s.prefixes = slices.Delete(s.prefixes, i, i+1)
+			}
+		}
+	})
+}
+
+// update 复制当前快照交给 fn 修改后整体替换；fn 返回的恢复函数在 ttl 到期后以同样方式执行
+func (c *LevelController) update(level slog.Level, ttl time.Duration, fn func(s *levelState, o levelOverride) func(*levelState)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	o := levelOverride{level: level, gen: c.gen}
+	if ttl > 0 {
+		o.expires = time.Now().Add(ttl)
+	}
+	s := c.state.Load().clone()
+	revert := fn(s, o)
+	c.store(s)
+
+	if ttl > 0 && revert != nil {
+		time.AfterFunc(ttl, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			s := c.state.Load().clone()
+			revert(s)
+			c.store(s)
+		})
+	}
+}
+
+func (c *LevelController) store(s *levelState) {
+	slices.SortStableFunc(s.prefixes, func(a, b prefixOverride) int { return len(b.prefix) - len(a.prefix) })
+	s.min = s.global.level
+	for _, o := range s.tags {
+		s.min = min(s.min, o.level)
+	}
+	for _, p := range s.prefixes {
+		s.min = min(s.min, p.level)
+	}
+	c.state.Store(s)
+}
+
+func (s *levelState) clone() *levelState {
+	cp := *s
+	cp.tags = make(map[string]levelOverride, len(s.tags)+1)
+	for k, v := range s.tags {
+		cp.tags[k] = v
+	}
+	cp.prefixes = slices.Clone(s.prefixes)
+	return &cp
+}
+
+// LevelInfo 某个级别设置的对外展示
+type LevelInfo struct {
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// LevelSnapshot 当前全部级别设置
+type LevelSnapshot struct {
+	LevelInfo
+	Tags     map[string]LevelInfo `json:"tags,omitempty"`
+	Prefixes map[string]LevelInfo `json:"prefixes,omitempty"`
+}
+
+func (o levelOverride) info() LevelInfo {
+	li := LevelInfo{Level: o.level.String()}
+	if !o.expires.IsZero() {
+		t := o.expires
+		li.ExpiresAt = &t
+	}
+	return li
+}
+
+// Snapshot 当前全部级别设置
+func (c *LevelController) Snapshot() LevelSnapshot {
+	s := c.state.Load()
+	snap := LevelSnapshot{LevelInfo: s.global.info()}
+	if len(s.tags) > 0 {
+		snap.Tags = make(map[string]LevelInfo, len(s.tags))
+		for k, o := range s.tags {
+			snap.Tags[k] = o.info()
+		}
+	}
+	if len(s.prefixes) > 0 {
+		snap.Prefixes = make(map[string]LevelInfo, len(s.prefixes))
+		for _, p := range s.prefixes {
+			snap.Prefixes[p.prefix] = p.info()
+		}
+	}
+	return snap
+}
+
+// levelRequest Handler 修改级别的请求体；tag / prefix 都为空时修改全局级别
+type levelRequest struct {
+	Level  string `json:"level"`
+	Tag    string `json:"tag"`
+	Prefix string `json:"prefix"`
+	TTL    string `json:"ttl"` // 如 "10m"，空表示不自动恢复
+}
+
+// Handler 查看 / 修改级别的 HTTP 接口，gin 中可用 gin.WrapH 挂载：
+//
+//	GET                                                   返回 LevelSnapshot
+//	PUT / POST {"level":"debug","tag":"http_success","ttl":"10m"}  设置级别（tag / prefix 为空时为全局）
+//	DELETE ?tag=http_success 或 ?prefix=httpclient/        删除覆盖
+//
+// 修改成功后同样返回 LevelSnapshot
+func (c *LevelController) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req levelRequest
+			if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+				http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := c.apply(req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		case http.MethodDelete:
+			q := r.URL.Query()
+			switch {
+			case q.Get("tag") != "":
+				c.ResetTag(q.Get("tag"))
+			case q.Get("prefix") != "":
+				c.ResetPrefix(q.Get("prefix"))
+			default:
+				http.Error(w, "tag or prefix is required", http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.Snapshot())
+	})
+}
+
+func (c *LevelController) apply(req levelRequest) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		return fmt.Errorf("invalid level %q", req.Level)
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid ttl %q", req.TTL)
+		}
+		ttl = d
+	}
+	switch {
+	case req.Tag != "" && req.Prefix != "":
+		return fmt.Errorf("tag and prefix are mutually exclusive")
+	case req.Tag != "":
+		c.SetTagLevel(req.Tag, level, ttl)
+	case req.Prefix != "":
+		c.SetPrefixLevel(req.Prefix, level, ttl)
+	default:
+		c.SetLevel(level, ttl)
+	}
+	return nil
+}
+
+// LevelsOf 返回 logger 使用的级别控制器（非 logx.New 创建的 logger 返回 nil）
+func LevelsOf(l Logger) *LevelController {
+	li, ok := l.(*loggerImpl)
+	if !ok || li == nil || li.slog == nil {
+		return nil
+	}
+	if h, ok := li.slog.Handler().(*handler); ok {
+		return h.levels
+	}
+	return nil
+}
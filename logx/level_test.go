@@ -0,0 +1,136 @@
+package logx
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelController(t *testing.T) {
+	c := NewLevelController(slog.LevelInfo)
+	c.SetTagLevel("db", slog.LevelDebug, 0)
+	c.SetPrefixLevel("httpclient/", slog.LevelError, 0)
+	c.SetPrefixLevel("httpclient/ws", slog.LevelDebug, 0)
+
+	cases := []struct {
+		level     slog.Level
+		tag, file string
+		want      bool
+	}{
+		{slog.LevelDebug, "db", "httpclient/do.go", true}, // tag 优先于前缀
+		{slog.LevelDebug, "other", "main.go", false},
+		{slog.LevelInfo, "other", "main.go", true},
+		{slog.LevelWarn, "other", "httpclient/do.go", false},
+		{slog.LevelDebug, "other", "httpclient/ws.go", true}, // 最长前缀
+	}
+	for i, tc := range cases {
+		if got := c.Enabled(tc.level, tc.tag, tc.file); got != tc.want {
+			t.Errorf("case %d: Enabled = %v, want %v", i, got, tc.want)
+		}
+	}
+	c.ResetPrefix("httpclient/")
+	if !c.Enabled(slog.LevelWarn, "other", "httpclient/do.go") {
+		t.Fatal("prefix override not reset")
+	}
+
+	// TTL 到期恢复；被后续修改覆盖的不再恢复
+	c.SetLevel(slog.LevelDebug, 20*time.Millisecond)
+	c.SetTagLevel("db", slog.LevelError, 20*time.Millisecond)
+	c.SetTagLevel("cache", slog.LevelDebug, 20*time.Millisecond)
+	c.SetTagLevel("cache", slog.LevelWarn, 0)
+	time.Sleep(100 * time.Millisecond)
+	if c.Level() != slog.LevelInfo || !c.Enabled(slog.LevelDebug, "db", "") {
+		t.Fatalf("not reverted: %+v", c.Snapshot())
+	}
+	if c.Enabled(slog.LevelInfo, "cache", "") {
+		t.Fatal("revert overwrote a later change")
+	}
+}
+
+// 先设短 TTL 再设长 TTL：长的到期时短的也已过期，不能恢复成它，而是恢复成它之前的值
+func TestLevelRevertSkipsExpired(t *testing.T) {
+	c := NewLevelController(slog.LevelInfo)
+	c.SetLevel(slog.LevelDebug, 20*time.Millisecond)
+	c.SetLevel(slog.LevelWarn, 60*time.Millisecond)
+	c.SetTagLevel("db", slog.LevelDebug, 20*time.Millisecond)
+	c.SetTagLevel("db", slog.LevelWarn, 60*time.Millisecond)
+	c.SetPrefixLevel("httpclient/", slog.LevelError, 0)
+	c.SetPrefixLevel("httpclient/", slog.LevelDebug, 20*time.Millisecond)
+	c.SetPrefixLevel("httpclient/", slog.LevelWarn, 60*time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+
+	snap := c.Snapshot()
+	if snap.Level != "INFO" || snap.ExpiresAt != nil {
+		t.Fatalf("global = %+v, want INFO without expiry", snap.LevelInfo)
+	}
+	if _, ok := snap.Tags["db"]; ok {
+		t.Fatalf("expired tag override restored: %+v", snap.Tags)
+	}
+	if p := snap.Prefixes["httpclient/"]; p.Level != "ERROR" || p.ExpiresAt != nil {
+		t.Fatalf("prefix = %+v, want ERROR without expiry", p)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	c := NewLevelController(slog.LevelInfo)
+	srv := httptest.NewServer(c.Handler())
+	defer srv.Close()
+
+	do := func(method, url, body string) (int, LevelSnapshot) {
+		req, _ := http.NewRequest(method, srv.URL+url, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var snap LevelSnapshot
+		_ = json.NewDecoder(resp.Body).Decode(&snap)
+		return resp.StatusCode, snap
+	}
+
+	if code, snap := do(http.MethodPut, "", `{"level":"debug","tag":"db","ttl":"1h"}`); code != 200 ||
+		snap.Tags["db"].Level != "DEBUG" || snap.Tags["db"].ExpiresAt == nil {
+		t.Fatalf("put tag: %d %+v", code, snap)
+	}
+	if code, snap := do(http.MethodPost, "", `{"level":"warn"}`); code != 200 || snap.Level != "WARN" {
+		t.Fatalf("put global: %d %+v", code, snap)
+	}
+	if code, _ := do(http.MethodPut, "", `{"level":"loud"}`); code != http.StatusBadRequest {
+		t.Fatalf("bad level accepted: %d", code)
+	}
+	if code, snap := do(http.MethodDelete, "?tag=db", ""); code != 200 || len(snap.Tags) != 0 {
+		t.Fatalf("delete: %d %+v", code, snap)
+	}
+	if code, snap := do(http.MethodGet, "", ""); code != 200 || snap.Level != "WARN" {
+		t.Fatalf("get: %d %+v", code, snap)
+	}
+}
+
+func TestLoggerRuntimeLevel(t *testing.T) {
+	l, dir := newTestLogger(t, Config{Level: slog.LevelInfo})
+	ctx := context.Background()
+	levels := LevelsOf(l)
+
+	l.Debug(ctx, "db", "hidden")
+	levels.SetTagLevel("db", slog.LevelDebug, 0)
+	l.Debug(ctx, "db", "visible")
+	levels.SetPrefixLevel("logx/", slog.LevelWarn, 0)
+	l.Info(ctx, "other", "muted by prefix")
+	l.Warn(ctx, "other", "warn kept")
+	if err := l.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	info := readLog(t, dir, "test.log")
+	if strings.Contains(info, "hidden") || !strings.Contains(info, "visible") || strings.Contains(info, "muted") {
+		t.Fatalf("unexpected output:\n%s", info)
+	}
+	if !strings.Contains(readLog(t, dir, "test.wf.log"), "warn kept") {
+		t.Fatal("warn missing")
+	}
+}
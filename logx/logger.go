@@ -12,6 +12,11 @@ type Logger interface {
 	Info(ctx context.Context, tag string, msg any, kv ...any)
 	Warn(ctx context.Context, tag string, msg any, kv ...any)
 	Error(ctx context.Context, tag string, msg any, kv ...any)
+
+	// Flush 等待已入队的日志写完并 fsync
+	Flush(ctx context.Context) error
+	// Close 写完队列、fsync 并关闭文件，之后的日志丢弃或同步写 stderr（Config.SyncAfterClose）
+	Close(ctx context.Context) error
 }
 
 type loggerImpl struct {
@@ -50,6 +55,28 @@ func (l *loggerImpl) log(ctx context.Context, level slog.Level, tag string, msg
 	_ = l.slog.Handler().Handle(ctx, rec)
 }
 
+// Flush 等待已入队的日志写完并 fsync（最多等到 ctx 结束）
+func (l *loggerImpl) Flush(ctx context.Context) error {
+	if l == nil || l.slog == nil {
+		return nil
+	}
+	if h, ok := l.slog.Handler().(*handler); ok {
+		return h.Flush(ctx)
+	}
+	return nil
+}
+
+// Close 停止接收新日志，等待异步队列写完并关闭文件（最多等到 ctx 结束）
+func (l *loggerImpl) Close(ctx context.Context) error {
+	if l == nil || l.slog == nil {
+		return nil
+	}
+	if h, ok := l.slog.Handler().(*handler); ok {
+		return h.Close(ctx)
+	}
+	return nil
+}
+
 // -------------------- 全局默认 logger --------------------
 
 var defaultLogger Logger
@@ -76,6 +103,22 @@ func New(cfg Config) (Logger, error) {
 	return &loggerImpl{slog: slog.New(h)}, nil
 }
 
+// Flush 刷新全局 logger，未 Init 时什么也不做
+func Flush(ctx context.Context) error {
+	if defaultLogger == nil {
+		return nil
+	}
+	return defaultLogger.Flush(ctx)
+}
+
+// Close 关闭全局 logger，建议在 main 退出前调用
+func Close(ctx context.Context) error {
+	if defaultLogger == nil {
+		return nil
+	}
+	return defaultLogger.Close(ctx)
+}
+
 // L 返回全局 logger，未 Init 时为 nil
 func L() Logger {
 	return defaultLogger
@@ -0,0 +1,264 @@
+package logx
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Compressor 历史日志文件的压缩算法；内置 GzipCompressor，zstd 等可自行实现后配置到 Config.Compressor
+type Compressor interface {
+	Ext() string // 压缩后追加的后缀，如 ".gz"
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// GzipCompressor gzip 压缩，Level 为 0 时使用 gzip.DefaultCompression
+type GzipCompressor struct {
+	Level int
+}
+
+func (GzipCompressor) Ext() string { return ".gz" }
+
+func (g GzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	if g.Level == 0 {
+		return gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	}
+	return gzip.NewWriterLevel(w, g.Level)
+}
+
+// histFile 目录中的一个历史日志文件
+type histFile struct {
+	name       string
+	warn       bool
+	size       int64
+	mtime      time.Time
+	compressed bool
+}
+
+// needJanitor 配置了压缩或任一保留策略时才启动后台整理
+func (h *handler) needJanitor() bool {
+	c := h.cfg
+	return c.Compressor != nil || c.MaxBackups > 0 || c.MaxAge > 0 || c.MaxTotalSizeMB > 0
+}
+
+// kickJanitor 通知后台整理（已有待处理的通知时合并）
+func (h *handler) kickJanitor() {
+	if h.tidyKick == nil {
+		return
+	}
+	select {
+	case h.tidyKick <- struct{}{}:
+	default:
+	}
+}
+
+// janitorLoop 后台压缩 + 清理：启动时、每次切分后、以及每小时（MaxAge 需要）各跑一次；
+// tidyStop 关闭后退出，正在压缩的文件会先做完
+func (h *handler) janitorLoop() {
+	defer close(h.tidyDone)
+	t := time.NewTicker(time.Hour)
+	defer t.Stop()
+	for {
+		select {
+		case <-h.tidyStop:
+			return
+		case <-h.tidyKick:
+		case <-t.C:
+		}
+		h.tidy()
+	}
+}
+
+// tidy 压缩历史文件，再按 MaxBackups / MaxAge / MaxTotalSizeMB 删除；当前打开的文件永远不动
+func (h *handler) tidy() {
+	files, openSize := h.listHistory()
+
+	if h.cfg.Compressor != nil {
+		for i := range files {
+			if files[i].compressed {
+				continue
+			}
+			if err := h.compress(&files[i]); err != nil {
+				log.Println("compress log failed:", err)
+			}
+		}
+	}
+
+	// 新的在前
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].mtime.After(files[j].mtime)
+	})
+
+	now := time.Now()
+	keep := make([]histFile, 0, len(files))
+	var infoN, warnN int
+	for _, f := range files {
+		n := &infoN
+		if f.warn {
+			n = &warnN
+		}
+		*n++
+		if (h.cfg.MaxBackups > 0 && *n > h.cfg.MaxBackups) ||
+			(h.cfg.MaxAge > 0 && now.Sub(f.mtime) > h.cfg.MaxAge) {
+			_ = os.Remove(f.name)
+			continue
+		}
+		keep = append(keep, f)
+	}
+
+	if h.cfg.MaxTotalSizeMB <= 0 {
+		return
+	}
+	limit := int64(h.cfg.MaxTotalSizeMB) * 1024 * 1024
+	total := openSize
+	for _, f := range keep {
+		total += f.size
+	}
+	for i := len(keep) - 1; i >= 0 && total > limit; i-- {
+		if err := os.Remove(keep[i].name); err == nil {
+			total -= keep[i].size
+		}
+	}
+}
+
+// listHistory 在持锁下列出 info / warn 历史文件（排除当前打开的文件），并返回打开文件的大小
+func (h *handler) listHistory() ([]histFile, int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	open := map[string]bool{}
+	var openSize int64
+	for _, lf := range []*logFile{&h.info, &h.warn} {
+		if lf.f != nil {
+			open[lf.name] = true
+			openSize += lf.size
+		}
+	}
+
+	entries, err := os.ReadDir(h.cfg.LogDir)
+	if err != nil {
+		log.Println("list log dir failed:", err)
+		return nil, openSize
+	}
+	files := make([]histFile, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() {
+			continue
+		}
+		f := histFile{name: filepath.Join(h.cfg.LogDir, name)}
+		if open[f.name] {
+			continue
+		}
+		base, compressed, ok := h.logSuffix(name)
+		if !ok {
+			continue
+		}
+		switch {
+		case h.warnName.MatchString(base):
+			f.warn = true
+		case h.infoName.MatchString(base):
+		default:
+			continue
+		}
+		f.compressed = compressed
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		f.size, f.mtime = info.Size(), info.ModTime()
+		files = append(files, f)
+	}
+	return files, openSize
+}
+
+// logSuffix 识别 .log / .log<压缩后缀>，返回去掉后缀的文件名
+func (h *handler) logSuffix(name string) (string, bool, bool) {
+	if base, ok := strings.CutSuffix(name, ".log"); ok {
+		return base, false, true
+	}
+	if c := h.cfg.Compressor; c != nil {
+		if base, ok := strings.CutSuffix(name, ".log"+c.Ext()); ok {
+			return base, true, true
+		}
+	}
+	return "", false, false
+}
+
+// compress 先写临时文件再 rename，最后删除原文件；压缩文件沿用原文件的修改时间，保留顺序不变
+func (h *handler) compress(f *histFile) (err error) {
+	dst := f.name + h.cfg.Compressor.Ext()
+	tmp := dst + ".tmp"
+
+	src, err := os.Open(f.name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	w, err := h.cfg.Compressor.NewWriter(out)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(w, src); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	if err = out.Sync(); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	_ = os.Chtimes(dst, f.mtime, f.mtime)
+	if err := os.Remove(f.name); err != nil {
+		return err
+	}
+
+	if info, e := os.Stat(dst); e == nil {
+		f.size = info.Size()
+	}
+	f.name, f.compressed = dst, true
+	return nil
+}
+
+// seqOf 从去掉 .log 后缀的文件名里解析周期序号："app-2026101615" -> 0，"app-2026101615.3" -> 3
+func seqOf(base, prefix string) (int, bool) {
+	rest, ok := strings.CutPrefix(base, prefix)
+	if !ok {
+		return 0, false
+	}
+	if rest == "" {
+		return 0, true
+	}
+	n, ok := strings.CutPrefix(rest, ".")
+	if !ok {
+		return 0, false
+	}
+	seq, err := strconv.Atoi(n)
+	if err != nil || seq <= 0 {
+		return 0, false
+	}
+	return seq, true
+}
@@ -0,0 +1,144 @@
+package logx
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeAged(t *testing.T, dir, name string, data []byte, age time.Duration) {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mt := time.Now().Add(-age)
+	if err := os.Chtimes(p, mt, mt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+// waitFor 等后台整理达到预期状态
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for janitor")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCompressAndMaxAge(t *testing.T) {
+	dir := t.TempDir()
+	cur := time.Now().Format("2006010215")
+	writeAged(t, dir, "app-2026010101.log", []byte("old\n"), 72*time.Hour)
+	writeAged(t, dir, "app.wf-2026010101.log", []byte("old\n"), 72*time.Hour)
+	writeAged(t, dir, "app-2026010102.log", []byte("recent\n"), time.Hour)
+	writeAged(t, dir, "app-"+cur+".log.gz", nil, time.Minute) // 本周期已被压缩的文件
+	writeAged(t, dir, "other-2026010101.log", []byte("x\n"), 72*time.Hour)
+
+	mode := RotateHourly
+	l, err := New(Config{
+		AppName: "app", LogDir: dir, Rotate: &mode,
+		MaxAge: 48 * time.Hour, Compressor: GzipCompressor{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := l.(*loggerImpl).slog.Handler().(*handler)
+	if got := filepath.Base(h.info.name); got != "app-"+cur+".1.log" {
+		t.Fatalf("opened %s after compressed file", got)
+	}
+
+	waitFor(t, func() bool {
+		return !exists(dir, "app-2026010101.log") && !exists(dir, "app.wf-2026010101.log") &&
+			exists(dir, "app-2026010102.log.gz") && !exists(dir, "app-2026010102.log")
+	})
+	if !exists(dir, "app-"+cur+".1.log") || !exists(dir, "app.wf-"+cur+".log") {
+		t.Fatal("open file touched")
+	}
+	if !exists(dir, "other-2026010101.log") {
+		t.Fatal("foreign file removed")
+	}
+
+	f, err := os.Open(filepath.Join(dir, "app-2026010102.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if fi, _ := f.Stat(); time.Since(fi.ModTime()) < 50*time.Minute {
+		t.Fatal("compressed file lost original mtime")
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != "recent\n" {
+		t.Fatalf("decompressed %q", b)
+	}
+	if err := l.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	blob := make([]byte, 600<<10)
+	_, _ = rand.Read(blob)
+	writeAged(t, dir, "app-2026010101.log", blob, 3*time.Hour)
+	writeAged(t, dir, "app.wf-2026010102.log", blob, 2*time.Hour)
+	writeAged(t, dir, "app-2026010103.log", blob, time.Hour)
+
+	mode := RotateHourly
+	l, err := New(Config{AppName: "app", LogDir: dir, Rotate: &mode, MaxTotalSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close(context.Background())
+
+	// 跨 info / warn 从最旧的删起，直到总大小不超过 1MB
+	waitFor(t, func() bool {
+		return !exists(dir, "app-2026010101.log") && !exists(dir, "app.wf-2026010102.log")
+	})
+	if !exists(dir, "app-2026010103.log") {
+		t.Fatal("newest history file removed")
+	}
+}
+
+func TestRetentionIgnoresOtherLoggers(t *testing.T) {
+	dir := t.TempDir()
+	// 同目录下名为 app-audit 的 logger，前缀同样是 "app-"
+	for _, name := range []string{"app-audit-2026010101.log", "app-audit.log", "app-2026010101-copy.log", "app.wf-x.log"} {
+		writeAged(t, dir, name, []byte("x\n"), 72*time.Hour)
+	}
+	writeAged(t, dir, "app-2026010101.3.log", []byte("mine\n"), 72*time.Hour)
+
+	mode := RotateHourly
+	l, err := New(Config{
+		AppName: "app", LogDir: dir, Rotate: &mode,
+		MaxAge: time.Hour, Compressor: GzipCompressor{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close(context.Background())
+
+	waitFor(t, func() bool { return !exists(dir, "app-2026010101.3.log") })
+	for _, name := range []string{"app-audit-2026010101.log", "app-audit.log", "app-2026010101-copy.log", "app.wf-x.log"} {
+		if !exists(dir, name) {
+			t.Fatalf("%s touched by another logger's retention", name)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package logx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultRotatePattern = "%Y%m%d%H"
+
+// logFile info 或 warn 一路输出当前打开的文件
+type logFile struct {
+	warn   bool
+	f      *os.File
+	name   string // 当前文件路径
+	size   int64
+	period string // 文件名中的时间部分
+	seq    int    // 同一周期内的序号，0 不带后缀
+}
+
+// periodPattern 时间切分用的格式，RotateSize 返回空（不按时间切）
+func (h *handler) periodPattern() string {
+	switch *h.cfg.Rotate {
+	case RotateHourly:
+		return "%Y%m%d%H"
+	case RotateDaily:
+		return "%Y%m%d"
+	case RotatePattern, RotateTimeSize:
+		if h.cfg.RotatePattern != "" {
+			return h.cfg.RotatePattern
+		}
+		return defaultRotatePattern
+	default:
+		return ""
+	}
+}
+
+// sizeLimit 按大小切分的上限，0 表示不按大小切
+func (h *handler) sizeLimit() int64 {
+	switch *h.cfg.Rotate {
+	case RotateSize, RotateTimeSize:
+		return int64(h.cfg.MaxFileSizeMB) * 1024 * 1024
+	default:
+		return 0
+	}
+}
+
+// rotateIfNeededLocked 在已上锁的情况下，根据配置判断是否需要切分 info/warn 文件
+func (h *handler) rotateIfNeededLocked(now time.Time) error {
+	rotated := false
+	for _, lf := range []*logFile{&h.info, &h.warn} {
+		ok, err := h.rotateFileLocked(lf, now)
+		if err != nil {
+			return err
+		}
+		rotated = rotated || ok
+	}
+
+	// 压缩、清理历史文件交给后台
+	if rotated {
+		h.kickJanitor()
+	}
+	return nil
+}
+
+// rotateFileLocked 周期变化时切到新周期，周期内超过大小时序号 +1；返回是否打开了新文件
+func (h *handler) rotateFileLocked(lf *logFile, now time.Time) (bool, error) {
+	pattern := h.periodPattern()
+	limit := h.sizeLimit()
+
+	period := lf.period
+	if pattern != "" {
+		period = strftime(pattern, now)
+	}
+	switch {
+	case lf.f == nil || period != lf.period:
+		if pattern == "" {
+			// 只按大小切时，文件名也带上日期，方便排查
+			period = now.Format("20060102")
+		}
+		// 启动或进入新周期：续写该周期已有的最后一个文件（重启不覆盖、不跳号），已被压缩则用下一个序号
+		seq, plain := h.lastSeq(lf.warn, period)
+		if !plain {
+			seq++
+		}
+		if err := h.openLocked(lf, period, seq); err != nil {
+			return false, err
+		}
+		if limit > 0 && lf.size >= limit {
+			return true, h.openLocked(lf, period, seq+1)
+		}
+		return true, nil
+	case limit > 0 && lf.size >= limit:
+		if pattern == "" {
+			period = now.Format("20060102")
+		}
+		seq, _ := h.lastSeq(lf.warn, period)
+		if period == lf.period {
+			seq = max(seq, lf.seq)
+		}
+		// 跨天后当前文件的序号属于前一天，新的一天从目录里已有的序号接着编
+		return true, h.openLocked(lf, period, seq+1)
+	}
+	return false, nil
+}
+
+// openLocked 关闭旧文件，打开（追加）period/seq 对应的文件，并更新软链接
+func (h *handler) openLocked(lf *logFile, period string, seq int) error {
+	if lf.f != nil {
+		_ = lf.f.Close()
+		lf.f = nil
+	}
+	if err := os.MkdirAll(h.cfg.LogDir, 0o755); err != nil {
+		return err
+	}
+	name := h.buildFilename(lf.warn, period, seq)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	var size int64
+	if fi, err := f.Stat(); err == nil {
+		size = fi.Size()
+	}
+	*lf = logFile{warn: lf.warn, f: f, name: name, size: size, period: period, seq: seq}
+
+	link := filepath.Join(h.cfg.LogDir, h.cfg.AppName+".log")
+	if lf.warn {
+		link = filepath.Join(h.cfg.LogDir, h.cfg.AppName+".wf.log")
+	}
+	_ = os.Remove(link)
+	_ = os.Symlink(filepath.Base(name), link)
+	return nil
+}
+
+// buildFilename 构造 info / warn 日志文件名：app-2026101615.log、app-2026101615.1.log、app.wf-2026101615.log
+func (h *handler) buildFilename(warn bool, period string, seq int) string {
+	prefix := h.infoPrefix()
+	if warn {
+		// warn 文件加 .wf 前缀，和常见 app.wf.log 习惯一致
+		prefix = h.warnPrefix()
+	}
+	if seq > 0 {
+		return filepath.Join(h.cfg.LogDir, fmt.Sprintf("%s%s.%d.log", prefix, period, seq))
+	}
+	return filepath.Join(h.cfg.LogDir, fmt.Sprintf("%s%s.log", prefix, period))
+}
+
+// lastSeq 目录里该周期已有文件（含已压缩的）的最大序号，没有返回 -1；
+// plain 表示该序号的未压缩文件仍在，可以续写
+func (h *handler) lastSeq(warn bool, period string) (last int, plain bool) {
+	prefix := h.infoPrefix()
+	if warn {
+		prefix = h.warnPrefix()
+	}
+	prefix += period
+
+	entries, err := os.ReadDir(h.cfg.LogDir)
+	if err != nil {
+		return -1, false
+	}
+	last = -1
+	for _, e := range entries {
+		base, compressed, ok := h.logSuffix(e.Name())
+		if !ok {
+			continue
+		}
+		seq, ok := seqOf(base, prefix)
+		if !ok {
+			continue
+		}
+		switch {
+		case seq > last:
+			last, plain = seq, !compressed
+		case seq == last && !compressed:
+			plain = true
+		}
+	}
+	return last, plain
+}
+
+// strftime 支持 %Y %y %m %d %H %M %S %j %%
+func strftime(pattern string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 == len(pattern) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'M':
+			b.WriteString(t.Format("04"))
+		case 'S':
+			b.WriteString(t.Format("05"))
+		case 'j':
+			b.WriteString(t.Format("002"))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
+
+// periodRegexp 时间格式对应的正则，用来识别文件名里的周期部分
+func periodRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != '%' || i+1 == len(pattern) {
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b.WriteString(`\d{4}`)
+		case 'y', 'm', 'd', 'H', 'M', 'S':
+			b.WriteString(`\d{2}`)
+		case 'j':
+			b.WriteString(`\d{3}`)
+		case '%':
+			// strftime 把 %% 写成一个 %
+			b.WriteString(`%`)
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i-1 : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// nameRegexp 本 logger 的文件名（去掉 .log 及压缩后缀）：前缀 + 周期 + 可选的 .序号；
+// 同目录下 app-audit 之类的其他 logger 的文件不会匹配
+func (h *handler) nameRegexp(prefix string) *regexp.Regexp {
+	pattern := h.periodPattern()
+	if pattern == "" {
+		pattern = "%Y%m%d"
+	}
+	return regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + periodRegexp(pattern) + `(\.[1-9][0-9]*)?$`)
+}
+
+// validatePattern 文件名里只允许出现时间占位符和普通字符，不能带路径；
+// 周期不能重复出现（如只有 %H 时每天都会回到同一个文件名，续写已经被压缩 / 清理的历史文件）
+func validatePattern(pattern string) error {
+	if strings.ContainsAny(pattern, `/\`) {
+		return fmt.Errorf("logx: rotate pattern %q must not contain path separators", pattern)
+	}
+	if !strings.Contains(pattern, "%") {
+		return fmt.Errorf("logx: rotate pattern %q has no time verb", pattern)
+	}
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			continue
+		}
+		i++
+		if i == len(pattern) || !strings.ContainsRune("YymdHMSj%", rune(pattern[i])) {
+			return fmt.Errorf("logx: unsupported verb in rotate pattern %q", pattern)
+		}
+	}
+
+	has := func(verb string) bool { return strings.Contains(pattern, "%"+verb) }
+	date := has("j") || (has("m") && has("d"))
+	switch {
+	case !has("Y") && !has("y"),
+		has("d") && !has("m"),
+		(has("H") || has("M") || has("S")) && !date,
+		has("M") && !has("H"),
+		has("S") && !has("M"):
+		return fmt.Errorf("logx: rotate pattern %q repeats, it must contain every coarser unit (year, date, hour, minute)", pattern)
+	}
+	return nil
+}
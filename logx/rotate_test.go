@@ -0,0 +1,159 @@
+package logx
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestStrftime(t *testing.T) {
+	ts := time.Date(2026, 10, 16, 15, 4, 5, 0, time.Local)
+	cases := map[string]string{
+		"%Y%m%d%H":       "2026101615",
+		"%Y-%m-%d":       "2026-10-16",
+		"%y%j_%H%M%S%%x": "26289_150405%x",
+	}
+	for p, want := range cases {
+		if got := strftime(p, ts); got != want {
+			t.Errorf("strftime(%q) = %q, want %q", p, got, want)
+		}
+	}
+	for _, p := range []string{"%Y/%m", "daily", "%Q", "%H", "%m%d%H", "%Y%H", "%Y%m%H", "%Y%d", "%Y%m%d%M", "%Y%j%H%S"} {
+		if validatePattern(p) == nil {
+			t.Errorf("pattern %q accepted", p)
+		}
+	}
+	for _, p := range []string{"%Y", "%Y-%m", "%Y%m%d", "%y%j%H", "%Y.%m.%d_%H%M", "%Y%m%d%H%M%S"} {
+		if err := validatePattern(p); err != nil {
+			t.Errorf("pattern %q rejected: %v", p, err)
+		}
+	}
+}
+
+// rotateAt 以指定时间触发一次切分检查，返回 info 文件名
+func rotateAt(t *testing.T, h *handler, now time.Time, full bool) string {
+	t.Helper()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if full {
+		h.info.size = h.sizeLimit()
+	}
+	if err := h.rotateIfNeededLocked(now); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Base(h.info.name)
+}
+
+func TestRotateTimeSize(t *testing.T) {
+	dir := t.TempDir()
+	mode := RotateTimeSize
+	cfg := Config{AppName: "app", LogDir: dir, Rotate: &mode, MaxFileSizeMB: 1}
+	newH := func() *handler {
+		sh, err := newHandler(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sh.(*handler)
+	}
+
+	h := newH()
+	t1 := time.Date(2026, 10, 16, 15, 0, 0, 0, time.Local)
+	steps := []struct {
+		now  time.Time
+		full bool
+		want string
+	}{
+		{t1, false, "app-2026101615.log"},
+		{t1.Add(time.Minute), false, "app-2026101615.log"},
+		{t1.Add(time.Minute), true, "app-2026101615.1.log"},
+		{t1.Add(time.Minute), true, "app-2026101615.2.log"},
+		{t1.Add(time.Hour), false, "app-2026101616.log"},
+	}
+	for i, s := range steps {
+		if got := rotateAt(t, h, s.now, s.full); got != s.want {
+			t.Fatalf("step %d: %s, want %s", i, got, s.want)
+		}
+	}
+	if got := filepath.Base(h.warn.name); got != "app.wf-2026101616.log" {
+		t.Fatalf("warn file %s", got)
+	}
+	_ = h.closeFiles()
+
+	// 重启后续写该周期最后一个文件，再切分时序号继续递增
+	h = newH()
+	defer h.closeFiles()
+	if got := rotateAt(t, h, t1, false); got != "app-2026101615.2.log" {
+		t.Fatalf("after restart: %s", got)
+	}
+	if got := rotateAt(t, h, t1, true); got != "app-2026101615.3.log" {
+		t.Fatalf("after restart: %s", got)
+	}
+}
+
+func TestRotateSizeSameSecond(t *testing.T) {
+	mode := RotateSize
+	sh, err := newHandler(Config{AppName: "app", LogDir: t.TempDir(), Rotate: &mode, MaxFileSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sh.(*handler)
+	defer h.closeFiles()
+
+	now := time.Now()
+	seen := map[string]bool{rotateAt(t, h, now, false): true}
+	for range 3 {
+		name := rotateAt(t, h, now, true)
+		if seen[name] {
+			t.Fatalf("file %s reused", name)
+		}
+		seen[name] = true
+	}
+}
+
+// 只按大小切时，跨天后的第一次切分从新日期的第一个文件开始，不沿用前一天的序号
+func TestRotateSizeAcrossDays(t *testing.T) {
+	mode := RotateSize
+	sh, err := newHandler(Config{AppName: "app", LogDir: t.TempDir(), Rotate: &mode, MaxFileSizeMB: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sh.(*handler)
+	defer h.closeFiles()
+	// 丢掉按当前时间打开的文件，从 day1 重新开始
+	_ = h.closeFiles()
+
+	day1 := time.Date(2026, 10, 16, 23, 59, 0, 0, time.Local)
+	day2 := day1.Add(2 * time.Minute)
+	steps := []struct {
+		now  time.Time
+		full bool
+		want string
+	}{
+		{day1, false, "app-20261016.log"},
+		{day1, true, "app-20261016.1.log"},
+		{day1, true, "app-20261016.2.log"},
+		{day2, false, "app-20261016.2.log"},
+		{day2, true, "app-20261017.log"},
+		{day2, true, "app-20261017.1.log"},
+	}
+	for i, s := range steps {
+		if got := rotateAt(t, h, s.now, s.full); got != s.want {
+			t.Fatalf("step %d: %s, want %s", i, got, s.want)
+		}
+	}
+}
+
+// periodRegexp 要匹配 strftime 实际写出的名字，%% 只写出一个 %
+func TestPeriodRegexpMatchesStrftime(t *testing.T) {
+	ts := time.Date(2026, 10, 16, 15, 4, 5, 0, time.Local)
+	for _, p := range []string{"%Y%m%d", "%Y-%m-%d_%H", "%y%j%%", "%Y%%%m.%d", "%Y%m%d%Q"} {
+		re := regexp.MustCompile(`^` + periodRegexp(p) + `$`)
+		if name := strftime(p, ts); !re.MatchString(name) {
+			t.Errorf("pattern %q: %s does not match %q", p, re, name)
+		}
+	}
+	if regexp.MustCompile(`^` + periodRegexp("%Y%%") + `$`).MatchString("2026%%") {
+		t.Error("%% matched two percent signs")
+	}
+}
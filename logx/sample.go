@@ -0,0 +1,233 @@
+package logx
+
+import (
+	"log"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SampleRule 采样规则：每个 Tick 周期内前 First 条全部保留，之后每 Thereafter 条保留 1 条；
+// 按 tag + 级别分别计数，多条规则时第一条匹配的生效
+type SampleRule struct {
+	Tag        string        // 空表示所有 tag
+	Levels     []slog.Level  // 空表示所有级别
+	First      int           // 每个周期内全部保留的条数
+	Thereafter int           // 超出 First 后每 Thereafter 条保留 1 条，0 表示全部丢弃
+	Tick       time.Duration // 计数周期，默认 1s
+}
+
+func (r SampleRule) tick() time.Duration {
+	if r.Tick <= 0 {
+		return time.Second
+	}
+	return r.Tick
+}
+
+func (r SampleRule) match(tag string, level slog.Level) bool {
+	if r.Tag != "" && r.Tag != tag {
+		return false
+	}
+	return len(r.Levels) == 0 || slices.Contains(r.Levels, level)
+}
+
+// recordKey 一条日志的身份：级别 + tag + msg + 调用位置，采样和去重都用它
+type recordKey struct {
+	level slog.Level
+	tag   string
+	msg   string
+	file  string
+	line  int64
+}
+
+func keyOf(r slog.Record) recordKey {
+	k := recordKey{level: r.Level}
+	r.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "tag":
+			k.tag = a.Value.String()
+		case Msg, "error":
+			k.msg = a.Value.String()
+		case "file":
+			k.file = a.Value.String()
+		case "line":
+			k.line = a.Value.Int64()
+		}
+		return true
+	})
+	return k
+}
+
+// sampler 按规则计数，决定一条日志是否保留
+type sampler struct {
+	rules        []SampleRule
+	bypassErrors bool
+	interval     time.Duration // 清理过期计数的间隔，取各规则 Tick 的最小值
+
+	mu       sync.Mutex
+	counters map[sampleKey]*sampleCounter
+}
+
+type sampleKey struct {
+	rule  int
+	tag   string
+	level slog.Level
+}
+
+type sampleCounter struct {
+	start time.Time
+	n     int
+}
+
+func newSampler(cfg Config) *sampler {
+	if len(cfg.Sampling) == 0 {
+		return nil
+	}
+	s := &sampler{
+		rules:        cfg.Sampling,
+		bypassErrors: cfg.SamplingBypassErrors,
+		counters:     make(map[sampleKey]*sampleCounter),
+	}
+	for i, r := range cfg.Sampling {
+		if i == 0 || r.tick() < s.interval {
+			s.interval = r.tick()
+		}
+	}
+	return s
+}
+
+func (s *sampler) allow(tag string, level slog.Level, now time.Time) bool {
+	if s == nil || (s.bypassErrors && level >= slog.LevelError) {
+		return true
+	}
+	idx := slices.IndexFunc(s.rules, func(r SampleRule) bool { return r.match(tag, level) })
+	if idx < 0 {
+		return true
+	}
+	rule := s.rules[idx]
+	tick := rule.tick()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := sampleKey{rule: idx, tag: tag, level: level}
+	c := s.counters[k]
+	if c == nil {
+		c = &sampleCounter{}
+		s.counters[k] = c
+	}
+	if now.Sub(c.start) >= tick {
+		c.start, c.n = now, 0
+	}
+	c.n++
+	if c.n <= rule.First {
+		return true
+	}
+	return rule.Thereafter > 0 && (c.n-rule.First)%rule.Thereafter == 0
+}
+
+// expire 清理周期已结束的计数，避免 tag 很多时 counters 无限增长；由 writeLoop 定期调用
+func (s *sampler) expire(now time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, c := range s.counters {
+		if now.Sub(c.start) >= s.rules[k.rule].tick() {
+			delete(s.counters, k)
+		}
+	}
+}
+
+// deduper 窗口内相同的日志只写第一条，其余计数；窗口结束时写一条带 repeated=N 的汇总
+type deduper struct {
+	window time.Duration
+
+	mu   sync.Mutex
+	seen map[recordKey]*dedupEntry
+}
+
+type dedupEntry struct {
+	start time.Time
+	n     int         // 被折叠的条数
+	last  slog.Record // 最后一条被折叠的日志，汇总以它为准
+}
+
+func newDeduper(cfg Config) *deduper {
+	if cfg.DedupWindow <= 0 {
+		return nil
+	}
+	return &deduper{window: cfg.DedupWindow, seen: make(map[recordKey]*dedupEntry)}
+}
+
+// check 返回 r 是否需要写出；r 开启了新窗口时，一并返回上一窗口的汇总
+func (d *deduper) check(k recordKey, r slog.Record, now time.Time) (bool, *slog.Record) {
+	if d == nil {
+		return true, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.seen[k]
+	if e != nil && now.Sub(e.start) < d.window {
+		e.n++
+		e.last = r.Clone()
+		return false, nil
+	}
+	var sum *slog.Record
+	if e != nil && e.n > 0 {
+		rec := e.summary()
+		sum = &rec
+	}
+	d.seen[k] = &dedupEntry{start: now}
+	return true, sum
+}
+
+// expired 取出窗口已结束（all 时为全部）的汇总，并清理过期的条目
+func (d *deduper) expired(now time.Time, all bool) []slog.Record {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []slog.Record
+	for k, e := range d.seen {
+		if !all && now.Sub(e.start) < d.window {
+			continue
+		}
+		if e.n > 0 {
+			out = append(out, e.summary())
+		}
+		delete(d.seen, k)
+	}
+	slices.SortFunc(out, func(a, b slog.Record) int { return a.Time.Compare(b.Time) })
+	return out
+}
+
+func (e *dedupEntry) summary() slog.Record {
+	rec := e.last.Clone()
+	rec.AddAttrs(slog.Int(Repeated, e.n))
+	return rec
+}
+
+// admit 依次做去重与采样，返回 r 是否写出，以及需要先写出的去重汇总（汇总不再参与采样）
+func (h *handler) admit(r slog.Record) (bool, *slog.Record) {
+	if h.dedup == nil && h.sampler == nil {
+		return true, nil
+	}
+	k := keyOf(r)
+	ok, sum := h.dedup.check(k, r, r.Time)
+	if !ok {
+		return false, nil
+	}
+	return h.sampler.allow(k.tag, r.Level, r.Time), sum
+}
+
+// flushDedup 由 writeLoop 调用，直接写出到期的去重汇总
+func (h *handler) flushDedup(now time.Time, all bool) {
+	for _, rec := range h.dedup.expired(now, all) {
+		if err := h.writeRecord(rec); err != nil {
+			log.Println("write log failed:", err)
+		}
+	}
+}
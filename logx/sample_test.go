@@ -0,0 +1,87 @@
+package logx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	s := newSampler(Config{
+		Sampling: []SampleRule{
+			{Tag: "hot", Levels: []slog.Level{slog.LevelWarn, slog.LevelError}, First: 3, Thereafter: 5},
+			{Tag: "mute", First: 1},
+		},
+		SamplingBypassErrors: true,
+	})
+	now := time.Now()
+	count := func(tag string, level slog.Level, at time.Time, n int) int {
+		kept := 0
+		for range n {
+			if s.allow(tag, level, at) {
+				kept++
+			}
+		}
+		return kept
+	}
+
+	if n := count("hot", slog.LevelWarn, now, 20); n != 6 { // 前 3 条 + 之后 17 条中每 5 条 1 条
+		t.Fatalf("kept %d, want 6", n)
+	}
+	if n := count("hot", slog.LevelWarn, now.Add(time.Second), 3); n != 3 {
+		t.Fatalf("new tick kept %d, want 3", n)
+	}
+	if n := count("hot", slog.LevelInfo, now, 20); n != 20 {
+		t.Fatalf("unmatched level kept %d", n)
+	}
+	if n := count("hot", slog.LevelError, now, 20); n != 20 {
+		t.Fatalf("error not bypassed: kept %d", n)
+	}
+	if n := count("mute", slog.LevelInfo, now, 20); n != 1 {
+		t.Fatalf("mute kept %d, want 1", n)
+	}
+}
+
+func TestSamplerExpire(t *testing.T) {
+	s := newSampler(Config{Sampling: []SampleRule{{Tag: "slow", First: 1, Tick: time.Minute}, {First: 1}}})
+	if s.interval != time.Second {
+		t.Fatalf("interval = %v, want 1s", s.interval)
+	}
+	now := time.Now()
+	for i := range 100 {
+		s.allow(fmt.Sprintf("tag%d", i), slog.LevelInfo, now)
+	}
+	s.allow("slow", slog.LevelInfo, now)
+
+	s.expire(now.Add(time.Second))
+	if len(s.counters) != 1 {
+		t.Fatalf("counters = %d after expire, want only the unexpired one", len(s.counters))
+	}
+	s.expire(now.Add(time.Minute))
+	if len(s.counters) != 0 {
+		t.Fatalf("counters = %d, want 0", len(s.counters))
+	}
+}
+
+func TestDedup(t *testing.T) {
+	l, dir := newTestLogger(t, Config{DedupWindow: time.Hour})
+	ctx := context.Background()
+	for range 50 {
+		l.Warn(ctx, TagUndef, "disk almost full")
+	}
+	l.Warn(ctx, TagUndef, "other")
+	if err := l.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	out := readLog(t, dir, "test.wf.log")
+	if n := strings.Count(out, "disk almost full"); n != 2 {
+		t.Fatalf("wrote %d lines, want first + summary:\n%s", n, out)
+	}
+	if !strings.Contains(out, `"repeated":49`) || strings.Count(out, `"msg":"other"`) != 1 {
+		t.Fatalf("missing summary:\n%s", out)
+	}
+}
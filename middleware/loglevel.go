@@ -0,0 +1,13 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/imattdu/orbit/logx"
+)
+
+// LogLevelHandler 在 gin 上挂载日志级别查看 / 修改接口，例如：
+//
+//	r.Any("/debug/log/level", middleware.LogLevelHandler(logx.LevelsOf(logx.L())))
+func LogLevelHandler(c *logx.LevelController) gin.HandlerFunc {
+	return gin.WrapH(c.Handler())
+}